@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+const deniedLogMessage = "Denied: user id %d isn't authorized"
+
+// Access modes of the bot.
+const (
+	// openAccess lets everyone except the banned users use the bot.
+	openAccess = "open"
+	// privateAccess lets only the allowed users use the bot.
+	privateAccess = "private"
+)
+
+// authorized reports whether the user can use the bot. The admins
+// can always use it, the banned users can't use it in any mode.
+func (app *application) authorized(userID int64) bool {
+	switch {
+	case app.admins[userID]:
+		return true
+	case app.users.Banned(userID):
+		return false
+	case app.access == privateAccess:
+		return app.users.Allowed(userID)
+	default:
+		return true
+	}
+}
+
+// denyMessage answers the message of the user that isn't authorized.
+// The message isn't processed, so the photos aren't downloaded. The
+// language of the user's Telegram app is used, so the languages of
+// the users that aren't authorized aren't remembered.
+func (app *application) denyMessage(m tg.Message) {
+	app.sendMessage(m.Chat.ID, app.printer(app.matchLang(m.From.LanguageCode)).Sprintf("Sorry, you don't have access to this bot. Ask its administrators for it."))
+}
+
+// denyCallbackQuery answers the callback query of the user that isn't authorized.
+func (app *application) denyCallbackQuery(q tg.CallbackQuery) {
+	err := app.bot.AnswerCallbackQuery(q.ID, app.printer(app.matchLang(q.From.LanguageCode)).Sprintf("Sorry, you don't have access to this bot. Ask its administrators for it."))
+	if err != nil {
+		app.errorLog.Printf("Error answering callback query: %s", err)
+	}
+}
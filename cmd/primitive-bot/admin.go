@@ -0,0 +1,351 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/params"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+const (
+	auditLogMessage     = "Admin: user id %d | %s | %s"
+	broadcastLogMessage = "Broadcast: sent to %d of %d users"
+	removedLogMessage   = "Removed: user id %d | input %s"
+)
+
+// maxMessageLength is the max length of the text of a Telegram message.
+const maxMessageLength = 4096
+
+// broadcastInterval is the pause between the messages of the broadcast,
+// so the bot doesn't exceed the limits of the Telegram Bot API.
+const broadcastInterval = 50 * time.Millisecond
+
+// adminCommands are the commands that only the admins can use.
+var adminCommands = map[string]bool{
+	"/queue":     true,
+	"/stats":     true,
+	"/drop":      true,
+	"/ban":       true,
+	"/unban":     true,
+	"/limit":     true,
+	"/broadcast": true,
+	"/allow":     true,
+	"/disallow":  true,
+	"/access":    true,
+}
+
+// authorizeCommand checks whether the user can use the command. The
+// attempts of the other users to use the admin commands are audited
+// and answered as if the commands didn't exist.
+func (app *application) authorizeCommand(m tg.Message, command string) bool {
+	if !adminCommands[command] || app.admins[m.From.ID] {
+		return true
+	}
+
+	app.auditLog.Printf(auditLogMessage, m.From.ID, m.Text, "denied")
+	app.sendMessage(m.Chat.ID, app.printer(app.userLang(m.From.ID)).Sprintf("Unrecognized command."))
+	return false
+}
+
+// processAdminCommand handles the admin command and adds its outcome
+// to the audit trail. The user must be authorized with authorizeCommand.
+func (app *application) processAdminCommand(m tg.Message, command string) {
+	args := strings.Fields(m.Text)[1:]
+
+	var outcome string
+	switch command {
+	case "/queue":
+		outcome = app.handleQueueCommand(m)
+	case "/stats":
+		outcome = app.handleStatsCommand(m)
+	case "/drop":
+		outcome = app.handleDropCommand(m, args)
+	case "/ban":
+		outcome = app.handleBanCommand(m, args)
+	case "/unban":
+		outcome = app.handleUnbanCommand(m, args)
+	case "/limit":
+		outcome = app.handleLimitCommand(m, args)
+	case "/broadcast":
+		outcome = app.handleBroadcastCommand(m, strings.TrimSpace(strings.TrimPrefix(m.Text, command)))
+	case "/allow":
+		outcome = app.handleAllowCommand(m, args)
+	case "/disallow":
+		outcome = app.handleDisallowCommand(m, args)
+	case "/access":
+		outcome = app.handleAccessCommand(m)
+	}
+
+	app.auditLog.Printf(auditLogMessage, m.From.ID, m.Text, outcome)
+}
+
+func (app *application) handleQueueCommand(m tg.Message) string {
+	lang := app.userLang(m.From.ID)
+	operations := app.queue.Operations()
+	if len(operations) == 0 {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The queue is empty."))
+		return "empty queue"
+	}
+
+	lines := make([]string, len(operations))
+	for i, op := range operations {
+		lines[i] = app.printer(lang).Sprintf("%d. User %d, input %s: %s", i+1, op.UserID, op.ImgPath, params.Format(op.Config))
+	}
+	app.sendLines(m.Chat.ID, lines)
+	return fmt.Sprintf("%d operations", len(operations))
+}
+
+func (app *application) handleStatsCommand(m tg.Message) string {
+	lang := app.userLang(m.From.ID)
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf(
+		"Operations in the queue: %d\nActive sessions: %d\nUsers: %d\nBanned users: %d\nUptime: %s",
+		len(app.queue.Operations()), app.sessions.Len(), len(app.users.Users()), len(app.users.BannedUsers()),
+		time.Since(app.started).Round(time.Second)))
+	return "ok"
+}
+
+// handleDropCommand removes the operation of the user with the input
+// from the queue. The operation is identified by them instead of
+// the position, because the position changes when the worker takes
+// the next operation. The continuations and the re-renders of the
+// results have the same input, so if the user has several waiting
+// operations with the input, the earliest one is removed.
+func (app *application) handleDropCommand(m tg.Message, args []string) string {
+	lang := app.userLang(m.From.ID)
+	if len(args) != 2 {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Usage: /drop <user ID> <input>"))
+		return "invalid arguments"
+	}
+	userID, ok := parseUserID(args[:1])
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Usage: /drop <user ID> <input>"))
+		return "invalid arguments"
+	}
+	imgPath := args[1]
+
+	op, ok := app.queue.Remove(userID, imgPath)
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf(
+			"The user %d doesn't have a waiting operation with the input %s.", userID, imgPath))
+		return "not found"
+	}
+	app.infoLog.Printf(removedLogMessage, op.UserID, op.ImgPath)
+
+	app.sendMessage(op.UserID,
+		app.printer(app.userLang(op.UserID)).Sprintf("Your operation was removed from the queue by the administrator."))
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The operation of the user %d is dropped.", op.UserID))
+	return fmt.Sprintf("dropped operation of user id %d with input %s", op.UserID, op.ImgPath)
+}
+
+func (app *application) handleBanCommand(m tg.Message, args []string) string {
+	lang := app.userLang(m.From.ID)
+	userID, ok := parseUserID(args)
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Usage: /ban <user ID>"))
+		return "invalid arguments"
+	}
+	if app.admins[userID] {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The administrators can't be banned."))
+		return "admin"
+	}
+
+	if err := app.users.Ban(userID); err != nil {
+		app.serverError(m.Chat.ID, err)
+		return fmt.Sprintf("error: %s", err)
+	}
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The user %d is banned.", userID))
+	return "banned"
+}
+
+func (app *application) handleUnbanCommand(m tg.Message, args []string) string {
+	lang := app.userLang(m.From.ID)
+	userID, ok := parseUserID(args)
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Usage: /unban <user ID>"))
+		return "invalid arguments"
+	}
+
+	ok, err := app.users.Unban(userID)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return fmt.Sprintf("error: %s", err)
+	}
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The user %d isn't banned.", userID))
+		return "not banned"
+	}
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The user %d is unbanned.", userID))
+	return "unbanned"
+}
+
+func (app *application) handleLimitCommand(m tg.Message, args []string) string {
+	lang := app.userLang(m.From.ID)
+	usage := app.printer(lang).Sprintf("Usage: /limit <user ID> <number of operations>, or /limit <user ID> to use the default limit")
+
+	if len(args) < 1 || len(args) > 2 {
+		app.sendMessage(m.Chat.ID, usage)
+		return "invalid arguments"
+	}
+	userID, ok := parseUserID(args[:1])
+	if !ok {
+		app.sendMessage(m.Chat.ID, usage)
+		return "invalid arguments"
+	}
+	n := 0
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+			app.sendMessage(m.Chat.ID, usage)
+			return "invalid arguments"
+		}
+	}
+
+	if err := app.users.SetLimit(userID, n); err != nil {
+		app.serverError(m.Chat.ID, err)
+		return fmt.Sprintf("error: %s", err)
+	}
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The user %d can add %d operations to the queue.",
+		userID, app.operationsLimitOf(userID)))
+	return fmt.Sprintf("limit %d", app.operationsLimitOf(userID))
+}
+
+func (app *application) handleBroadcastCommand(m tg.Message, text string) string {
+	lang := app.userLang(m.From.ID)
+	if text == "" {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Usage: /broadcast <text>"))
+		return "invalid arguments"
+	}
+
+	// the users are messaged in the background, so
+	// the other commands of the admin aren't blocked
+	recipients := app.users.Users()
+	go func() {
+		sent := 0
+		for _, userID := range recipients {
+			if !app.authorized(userID) {
+				continue
+			}
+			if _, err := app.bot.SendMessage(userID, text); err != nil {
+				app.errorLog.Printf("Error broadcasting to the user %d: %s", userID, err)
+			} else {
+				sent++
+			}
+			time.Sleep(broadcastInterval)
+		}
+
+		app.infoLog.Printf(broadcastLogMessage, sent, len(recipients))
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The message is sent to %d of %d users.", sent, len(recipients)))
+	}()
+
+	return fmt.Sprintf("started for %d users", len(recipients))
+}
+
+func (app *application) handleAllowCommand(m tg.Message, args []string) string {
+	lang := app.userLang(m.From.ID)
+	userID, ok := parseUserID(args)
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Usage: /allow <user ID>"))
+		return "invalid arguments"
+	}
+
+	if err := app.users.Allow(userID); err != nil {
+		app.serverError(m.Chat.ID, err)
+		return fmt.Sprintf("error: %s", err)
+	}
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The user %d is allowed.", userID))
+	return "allowed"
+}
+
+func (app *application) handleDisallowCommand(m tg.Message, args []string) string {
+	lang := app.userLang(m.From.ID)
+	userID, ok := parseUserID(args)
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Usage: /disallow <user ID>"))
+		return "invalid arguments"
+	}
+
+	ok, err := app.users.Disallow(userID)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return fmt.Sprintf("error: %s", err)
+	}
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The user %d isn't allowed.", userID))
+		return "not allowed"
+	}
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The user %d is disallowed.", userID))
+	return "disallowed"
+}
+
+func (app *application) handleAccessCommand(m tg.Message) string {
+	p := app.printer(app.userLang(m.From.ID))
+	mode := p.Sprintf("everyone except the banned users")
+	if app.access == privateAccess {
+		mode = p.Sprintf("only the allowed users")
+	}
+
+	app.sendLines(m.Chat.ID, []string{
+		p.Sprintf("The bot serves %s.", mode),
+		p.Sprintf("Allowed users: %s", formatUserIDs(app.users.AllowedUsers())),
+		p.Sprintf("Banned users: %s", formatUserIDs(app.users.BannedUsers())),
+	})
+	return "ok"
+}
+
+// rememberUser adds the user to the recipients of the broadcasts.
+func (app *application) rememberUser(userID int64) {
+	if err := app.users.Add(userID); err != nil {
+		app.errorLog.Printf("Error saving user: %s", err)
+	}
+}
+
+// operationsLimitOf returns the number of operations that the user can add to
+// the queue. The limit set by the admins takes precedence over the default one.
+func (app *application) operationsLimitOf(userID int64) int {
+	if n, ok := app.users.Limit(userID); ok {
+		return n
+	}
+	return app.operationsLimit
+}
+
+// sendLines sends the lines in as few messages as possible.
+func (app *application) sendLines(chatID int64, lines []string) {
+	var b strings.Builder
+	for _, line := range lines {
+		if b.Len() > 0 && b.Len()+len(line)+1 > maxMessageLength {
+			app.sendMessage(chatID, b.String())
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+	}
+	app.sendMessage(chatID, b.String())
+}
+
+// formatUserIDs returns the comma-separated list of the user IDs.
+func formatUserIDs(ids []int64) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+
+	list := make([]string, len(ids))
+	for i, id := range ids {
+		list[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(list, ", ")
+}
+
+// parseUserID parses the only argument of the command as the user ID.
+func parseUserID(args []string) (int64, bool) {
+	if len(args) != 1 {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	return id, err == nil
+}
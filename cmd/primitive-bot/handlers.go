@@ -3,42 +3,105 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/lazy-void/primitive-bot/pkg/params"
+	"github.com/lazy-void/primitive-bot/pkg/presets"
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
 	"github.com/lazy-void/primitive-bot/pkg/queue"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
 
 	"github.com/lazy-void/primitive-bot/pkg/menu"
 
 	"github.com/lazy-void/primitive-bot/pkg/sessions"
+	"github.com/lazy-void/primitive-bot/pkg/styles"
 )
 
 func (app *application) showRootMenuView(s sessions.Session) {
+	// the summary of the settings could be changed in the other views
+	s.Menu.RootView = app.menuBuilder(s.Lang).NewRootView(s.Config)
+	app.sessions.Set(s)
+
 	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RootView)
 }
 
-func (app *application) handleCreateButton(s sessions.Session, callbackID string) {
-	n := app.queue.GetNumOperations(s.UserID)
-	if n >= app.operationsLimit {
-		err := app.bot.AnswerCallbackQuery(callbackID,
-			app.printer.Sprintf("You can't add more operations to the queue."))
+func (app *application) handleUndoButton(s sessions.Session, callbackID string) {
+	undone, ok := app.sessions.Undo(s.UserID, s.MenuMessageID)
+	if !ok {
+		err := app.bot.AnswerCallbackQuery(callbackID, app.printer(s.Lang).Sprintf("There is nothing to undo."))
+		if err != nil {
+			app.serverError(s.UserID, err)
+		}
+		return
+	}
+
+	s = app.applyConfig(undone, undone.Config)
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RootView)
+}
+
+func (app *application) handleRedoButton(s sessions.Session, callbackID string) {
+	redone, ok := app.sessions.Redo(s.UserID, s.MenuMessageID)
+	if !ok {
+		err := app.bot.AnswerCallbackQuery(callbackID, app.printer(s.Lang).Sprintf("There is nothing to redo."))
 		if err != nil {
 			app.serverError(s.UserID, err)
 		}
 		return
 	}
 
-	app.infoLog.Printf(enqueuedLogMessage, s.UserID, s.ImgPath, s.Config.Iterations,
-		s.Config.Shape, s.Config.Alpha, s.Config.Repeat, s.Config.OutputSize, s.Config.Extension)
-	pos := app.queue.Enqueue(queue.Operation{
+	s = app.applyConfig(redone, redone.Config)
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RootView)
+}
+
+func (app *application) handleCreateButton(s sessions.Session, callbackID string) {
+	pos, ok := app.enqueue(queue.Operation{
 		UserID:  s.UserID,
 		ImgPath: s.ImgPath,
 		Config:  s.Config,
 	})
+	if !ok {
+		err := app.bot.AnswerCallbackQuery(callbackID,
+			app.printer(s.Lang).Sprintf("You can't add more operations to the queue."))
+		if err != nil {
+			app.serverError(s.UserID, err)
+		}
+		return
+	}
+
+	err := app.bot.AnswerCallbackQuery(callbackID, app.printer(s.Lang).Sprintf("Added to the queue. Position: %d.", pos))
+	if err != nil {
+		app.serverError(s.UserID, err)
+	}
+}
+
+func (app *application) handleDefaultsPin(s sessions.Session, callbackID string) {
+	if err := app.prefs.Pin(s.UserID, s.Config); err != nil {
+		app.serverError(s.UserID, err)
+		return
+	}
+
+	err := app.bot.AnswerCallbackQuery(callbackID,
+		app.printer(s.Lang).Sprintf("The current settings will be used for the new images."))
+	if err != nil {
+		app.serverError(s.UserID, err)
+	}
+}
 
-	err := app.bot.AnswerCallbackQuery(callbackID, app.printer.Sprintf("Added to the queue. Position: %d.", pos))
+func (app *application) handleDefaultsReset(s sessions.Session, callbackID string) {
+	if err := app.prefs.Delete(s.UserID); err != nil {
+		app.serverError(s.UserID, err)
+		return
+	}
+	s = app.applyConfig(s, primitive.New(app.workers))
+
+	err := app.bot.AnswerCallbackQuery(callbackID, app.printer(s.Lang).Sprintf("The settings are reset to defaults."))
 	if err != nil {
 		app.serverError(s.UserID, err)
 	}
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RootView)
 }
 
 func (app *application) showShapesMenuView(s sessions.Session) {
@@ -46,170 +109,330 @@ func (app *application) showShapesMenuView(s sessions.Session) {
 }
 
 func (app *application) handleShapesButton(s sessions.Session, n int) {
-	s.Config.Shape = primitive.Shape(n)
-	app.sessions.Set(s.UserID, s, false)
-
-	// update menu
-	selected := fmt.Sprintf("%s/%d", menu.ShapesViewCallback, s.Config.Shape)
-	s.Menu.ShapesView = menu.NewMenuView(menu.ShapesViewTmpl, selected)
-	app.sessions.Set(s.UserID, s, false)
+	s.Config.Shapes = s.Config.Shapes.Toggle(primitive.Shape(n))
+	s.Menu.ShapesView = app.menuBuilder(s.Lang).NewShapesView(s.Config.Shapes)
+	app.sessions.Set(s)
 
 	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.ShapesView)
 }
 
-func (app *application) showIterMenuView(s sessions.Session) {
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.IterView)
+func (app *application) showOptionMenuView(s sessions.Session, o menu.Option) {
+	app.showMenuView(s.UserID, s.MenuMessageID, o.View(s.Config))
 }
 
-func (app *application) handleIterButton(s sessions.Session, n int) {
-	if n > app.maxIter {
+func (app *application) handleOptionButton(s sessions.Session, o menu.Option, value string) {
+	c, ok := o.Apply(s.Config, value)
+	if !ok {
 		return
 	}
-	s.Config.Iterations = n
-	app.sessions.Set(s.UserID, s, false)
-
-	// update menu
-	selected := fmt.Sprintf("%s/%d", menu.IterViewCallback, s.Config.Iterations)
-	s.Menu.IterView = menu.NewMenuView(menu.IterViewTmpl, selected)
-	app.sessions.Set(s.UserID, s, false)
+	s.Config = c
+	app.sessions.Set(s)
 
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.IterView)
+	app.showMenuView(s.UserID, s.MenuMessageID, o.View(s.Config))
 }
 
-func (app *application) handleIterInput(s sessions.Session) {
-	num, err := app.getInputFromUser(s, 1, app.maxIter)
-	if errors.Is(err, errSessionTerminated) {
-		// If the input menu was closed
-		return
-	} else if err != nil {
-		app.serverError(s.UserID, err)
-		return
+func (app *application) handleOptionInput(s sessions.Session, o menu.Option) {
+	max := o.Max
+	if max == 0 {
+		max = math.MaxInt32
 	}
 
-	s.Config.Iterations = num
-	app.sessions.Set(s.UserID, s, false)
-
-	buttonText := fmt.Sprintf("%s (%d)", menu.OtherButtonText, s.Config.Iterations)
-	s.Menu.IterView = menu.NewMenuView(
-		menu.IterViewTmpl, menu.IterInputCallback, buttonText,
-	)
-	app.sessions.Set(s.UserID, s, false)
-
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.IterView)
+	app.openDialog(s, sessions.Dialog{
+		Kind:  sessions.IntInput,
+		Field: o.Name,
+		Min:   float64(o.Min),
+		Max:   float64(max),
+	})
 }
 
-func (app *application) showRepMenuView(s sessions.Session) {
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RepView)
+func (app *application) showStopMenuView(s sessions.Session) {
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.StopView)
 }
 
-func (app *application) handleRepButton(s sessions.Session, n int) {
-	s.Config.Repeat = n
-	app.sessions.Set(s.UserID, s, false)
+func (app *application) showScheduleMenuView(s sessions.Session) {
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.ScheduleView)
+}
 
-	// update menu
-	selected := fmt.Sprintf("%s/%d", menu.RepViewCallback, s.Config.Repeat)
-	s.Menu.RepView = menu.NewMenuView(menu.RepViewTmpl, selected)
-	app.sessions.Set(s.UserID, s, false)
+func (app *application) handleScheduleOff(s sessions.Session) {
+	s.Config.Schedule = ""
+	s.Menu.ScheduleView = app.menuBuilder(s.Lang).NewScheduleView(s.Config.Schedule)
+	app.sessions.Set(s)
 
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RepView)
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.ScheduleView)
 }
 
-func (app *application) showAlphaMenuView(s sessions.Session) {
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.AlphaView)
+func (app *application) handleScheduleInput(s sessions.Session) {
+	app.openDialog(s, sessions.Dialog{
+		Kind:  sessions.TextInput,
+		Field: scheduleField,
+	})
 }
 
-func (app *application) handleAlphaButton(s sessions.Session, n int) {
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.AlphaView)
+func (app *application) showStylesMenuView(s sessions.Session) {
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.StylesView)
+}
 
-	if n < 0 || n > 255 {
+func (app *application) handleStyleButton(s sessions.Session, id string) {
+	style, ok := styles.Find(app.styles, id)
+	if !ok {
 		return
 	}
-	s.Config.Alpha = n
-	app.sessions.Set(s.UserID, s, false)
+
+	s = app.applyConfig(s, style.Apply(s.Config))
 
 	// update menu
-	selected := fmt.Sprintf("%s/%d", menu.AlphaViewCallback, s.Config.Alpha)
-	s.Menu.AlphaView = menu.NewMenuView(menu.AlphaViewTmpl, selected)
-	app.sessions.Set(s.UserID, s, false)
+	selected := fmt.Sprintf("%s/%s", menu.StylesViewCallback, id)
+	s.Menu.StylesView = menu.NewMenuView(app.menuBuilder(s.Lang).StylesViewTmpl, selected)
+	app.sessions.Set(s)
+
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.StylesView)
+}
 
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.AlphaView)
+func (app *application) showPresetsMenuView(s sessions.Session) {
+	view, err := app.presetsView(s)
+	if err != nil {
+		app.serverError(s.UserID, err)
+		return
+	}
+	app.showMenuView(s.UserID, s.MenuMessageID, view)
 }
 
-func (app *application) handleAlphaInput(s sessions.Session) {
-	num, err := app.getInputFromUser(s, 1, 255)
-	if errors.Is(err, errSessionTerminated) {
-		// If the input menu was closed
+func (app *application) handlePresetButton(s sessions.Session, name string) {
+	p, err := app.presets.Get(s.UserID, name)
+	if errors.Is(err, presets.ErrNotFound) {
+		// the preset was deleted with the other menu
+		app.showPresetsMenuView(s)
 		return
 	} else if err != nil {
 		app.serverError(s.UserID, err)
 		return
 	}
+	app.applyConfig(s, p.Config)
+
+	view, err := app.presetsView(s)
+	if err != nil {
+		app.serverError(s.UserID, err)
+		return
+	}
+	selected := fmt.Sprintf("%s/%s", menu.PresetsViewCallback, name)
+	app.showMenuView(s.UserID, s.MenuMessageID, menu.NewMenuView(view, selected))
+}
+
+func (app *application) handlePresetDelete(s sessions.Session, name string) {
+	err := app.presets.Delete(s.UserID, name)
+	if err != nil && !errors.Is(err, presets.ErrNotFound) {
+		app.serverError(s.UserID, err)
+		return
+	}
+
+	app.showPresetsMenuView(s)
+}
+
+func (app *application) handleConfigCommand(m tg.Message, text string) {
+	lang := app.userLang(m.From.ID)
+	s, ok := app.sessions.Last(m.From.ID)
+	if !ok {
+		app.sendMessage(m.Chat.ID,
+			app.printer(lang).Sprintf("Send me an image first, or send it with the parameters in the caption."))
+		return
+	}
+	if text == "" {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("config message %s", params.Format(s.Config)))
+		return
+	}
+
+	c, err := app.parseParams(text, s.Config)
+	var e *params.Error
+	if errors.As(err, &e) {
+		app.sendMessage(m.Chat.ID, app.paramsErrorMessage(lang, e))
+		return
+	}
 
-	s.Config.Alpha = num
-	app.sessions.Set(s.UserID, s, false)
+	s = app.applyConfig(s, c)
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RootView)
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("The settings are updated."))
+}
 
-	buttonText := fmt.Sprintf("%s (%d)", menu.OtherButtonText, s.Config.Alpha)
-	s.Menu.AlphaView = menu.NewMenuView(
-		menu.AlphaViewTmpl, menu.AlphaInputCallback, buttonText,
-	)
-	app.sessions.Set(s.UserID, s, false)
+func (app *application) handleSaveCommand(m tg.Message, name string) {
+	lang := app.userLang(m.From.ID)
+	s, ok := app.sessions.Last(m.From.ID)
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Send me an image first, then save the settings from its menu."))
+		return
+	}
+	if name == "" {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Specify the name of the preset: /save <name>"))
+		return
+	}
 
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.AlphaView)
+	err := app.presets.Save(m.From.ID, name, s.Config)
+	switch {
+	case errors.Is(err, presets.ErrInvalidName):
+		app.sendMessage(m.Chat.ID,
+			app.printer(lang).Sprintf("Incorrect name! It can contain only letters, digits, '_' and '-' and be up to 20 characters long."))
+	case errors.Is(err, presets.ErrLimit):
+		app.sendMessage(m.Chat.ID,
+			app.printer(lang).Sprintf("You can't save more than %d presets. Delete some of them first.", app.presetsLimit))
+	case err != nil:
+		app.serverError(m.Chat.ID, err)
+	default:
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Preset '%s' is saved.", name))
+	}
 }
 
-func (app *application) showExtMenuView(s sessions.Session) {
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.ExtView)
+func (app *application) handlePresetsCommand(m tg.Message) {
+	lang := app.userLang(m.From.ID)
+	list, err := app.presets.List(m.From.ID)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return
+	}
+	if len(list) == 0 {
+		app.sendMessage(m.Chat.ID,
+			app.printer(lang).Sprintf("You don't have any presets yet. Save the current settings with the command /save <name>."))
+		return
+	}
+
+	names := make([]string, len(list))
+	for i, p := range list {
+		names[i] = fmt.Sprintf("• %s", p.Name)
+	}
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("presets list %s", strings.Join(names, "\n")))
 }
 
-func (app *application) handleExtButton(s sessions.Session, ext string) {
-	s.Config.Extension = ext
-	app.sessions.Set(s.UserID, s, false)
+func (app *application) handlePresetCommand(m tg.Message, name string) {
+	lang := app.userLang(m.From.ID)
+	s, ok := app.sessions.Last(m.From.ID)
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Send me an image first, then apply the preset to it."))
+		return
+	}
+
+	p, err := app.presets.Get(m.From.ID, name)
+	if errors.Is(err, presets.ErrNotFound) {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("There is no preset '%s'.", name))
+		return
+	} else if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return
+	}
 
-	// update menu
-	selected := fmt.Sprintf("%s/%s", menu.ExtViewCallback, s.Config.Extension)
-	s.Menu.ExtView = menu.NewMenuView(menu.ExtViewTmpl, selected)
-	app.sessions.Set(s.UserID, s, false)
+	s = app.applyConfig(s, p.Config)
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RootView)
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Preset '%s' is applied.", name))
+}
+
+func (app *application) handleReopenButton(q tg.CallbackQuery, imgName string) {
+	lang := app.userLang(q.From.ID)
+	path := filepath.Join(app.inDir, imgName)
+	if _, err := os.Stat(path); err != nil {
+		err := app.bot.AnswerCallbackQuery(q.ID,
+			app.printer(lang).Sprintf("The image is no longer available. Send it again."))
+		if err != nil {
+			app.serverError(q.From.ID, err)
+		}
+		app.showMenuView(q.From.ID, q.Message.MessageID, app.menuBuilder(lang).NewExpiredView(""))
+		return
+	}
+
+	// the menu is reopened with the settings the user used last time
+	c, err := app.prefs.Config(q.From.ID)
+	if err != nil {
+		app.serverError(q.From.ID, err)
+		return
+	}
+
+	s := sessions.NewSession(q.From.ID, q.Message.MessageID, path, app.menuBuilder(lang), app.clampConfig(c))
+	app.addSession(s)
+	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.RootView)
+}
 
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.ExtView)
+func (app *application) showResultKeyboard(q tg.CallbackQuery, id string) {
+	app.editResultKeyboard(q, app.menuBuilder(app.userLang(q.From.ID)).NewResultKeyboard(id))
 }
 
-func (app *application) showSizeMenuView(s sessions.Session) {
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.SizeView)
+func (app *application) showResultSizeKeyboard(q tg.CallbackQuery, id string) {
+	app.editResultKeyboard(q, app.menuBuilder(app.userLang(q.From.ID)).NewResultSizeKeyboard(id))
 }
 
-func (app *application) handleSizeButton(s sessions.Session, n int) {
+func (app *application) handleResultSizeButton(q tg.CallbackQuery, id string, n int) {
 	if n < 256 || n > app.maxSize {
 		return
 	}
-	s.Config.OutputSize = n
-	app.sessions.Set(s.UserID, s, false)
 
-	// update menu
-	selected := fmt.Sprintf("%s/%d", menu.SizeViewCallback, s.Config.OutputSize)
-	s.Menu.SizeView = menu.NewMenuView(menu.SizeViewTmpl, selected)
-	app.sessions.Set(s.UserID, s, false)
+	r, ok := app.getResult(q, id)
+	if !ok {
+		return
+	}
 
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.SizeView)
+	c := r.Config
+	c.OutputSize = n
+	app.renderResult(q, r, c)
 }
 
-func (app *application) handleSizeInput(s sessions.Session) {
-	num, err := app.getInputFromUser(s, 256, app.maxSize)
-	if errors.Is(err, errSessionTerminated) {
-		// If the input menu was closed
+func (app *application) showResultExtKeyboard(q tg.CallbackQuery, id string) {
+	app.editResultKeyboard(q, app.menuBuilder(app.userLang(q.From.ID)).NewResultExtKeyboard(id))
+}
+
+func (app *application) handleResultExtButton(q tg.CallbackQuery, id, ext string) {
+	r, ok := app.getResult(q, id)
+	if !ok {
 		return
-	} else if err != nil {
-		app.serverError(s.UserID, err)
+	}
+
+	c := r.Config
+	c.Extension = ext
+	app.renderResult(q, r, c)
+}
+
+func (app *application) handleResultMoreButton(q tg.CallbackQuery, id string, n int) {
+	lang := app.userLang(q.From.ID)
+	if n < 1 || n > app.maxIter {
+		return
+	}
+
+	num := app.queue.GetNumOperations(q.From.ID)
+	if num >= app.operationsLimitOf(q.From.ID) {
+		err := app.bot.AnswerCallbackQuery(q.ID,
+			app.printer(lang).Sprintf("You can't add more operations to the queue."))
+		if err != nil {
+			app.serverError(q.From.ID, err)
+		}
 		return
 	}
 
-	s.Config.OutputSize = num
-	app.sessions.Set(s.UserID, s, false)
+	r, ok := app.getResult(q, id)
+	if !ok {
+		return
+	}
+	// continuing the result can't exceed the limit set by the operator
+	if r.Scene.Steps+n > app.maxIter {
+		err := app.bot.AnswerCallbackQuery(q.ID,
+			app.printer(lang).Sprintf("The image can't have more than %d steps.", app.maxIter))
+		if err != nil {
+			app.serverError(q.From.ID, err)
+		}
+		return
+	}
 
-	buttonText := fmt.Sprintf("%s (%d)", menu.OtherButtonText, s.Config.OutputSize)
-	s.Menu.SizeView = menu.NewMenuView(
-		menu.SizeViewTmpl, menu.SizeInputCallback, buttonText,
-	)
-	app.sessions.Set(s.UserID, s, false)
+	// the user asked for more steps explicitly, so only
+	// the time limit is left from the stop conditions
+	c := r.Config
+	c.Iterations = n
+	c.TargetScore = 0
+	c.MinImprovement = 0
+
+	op := queue.Operation{
+		UserID:   q.From.ID,
+		ImgPath:  r.ImgPath,
+		ResultID: r.ID,
+		Config:   c,
+	}
+	app.logEnqueued(op)
+	pos := app.queue.Enqueue(op)
 
-	app.showMenuView(s.UserID, s.MenuMessageID, s.Menu.SizeView)
+	err := app.bot.AnswerCallbackQuery(q.ID, app.printer(lang).Sprintf("Added to the queue. Position: %d.", pos))
+	if err != nil {
+		app.serverError(q.From.ID, err)
+	}
 }
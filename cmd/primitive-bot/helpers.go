@@ -3,19 +3,24 @@ package main
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/lazy-void/primitive-bot/pkg/menu"
+	"github.com/lazy-void/primitive-bot/pkg/params"
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
+	"github.com/lazy-void/primitive-bot/pkg/queue"
+	"github.com/lazy-void/primitive-bot/pkg/results"
 	"github.com/lazy-void/primitive-bot/pkg/sessions"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
 )
 
-var errSessionTerminated = errors.New("session terminated")
-
 func (app *application) serverError(chatID int64, err error) {
 	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
 
@@ -25,67 +30,19 @@ func (app *application) serverError(chatID int64, err error) {
 	}
 
 	_, err = app.bot.SendMessage(chatID,
-		app.printer.Sprintf("Something gone wrong! Please, try again in a few minutes."))
+		app.printer(app.userLang(chatID)).Sprintf("Something gone wrong! Please, try again in a few minutes."))
 	if err != nil {
 		app.errorLog.Print(err)
 	}
 }
 
-func (app *application) createStatusMessage(c primitive.Config, position int) string {
-	return app.printer.Sprintf(
+func (app *application) createStatusMessage(lang string, c primitive.Config, position int) string {
+	return app.printer(lang).Sprintf(
 		"%d place in the queue.\n\nShapes: %s\nSteps: %d\nRepetitions: %d\nAlpha-channel: %d\nExtension: %s\nSize: %#v",
-		position, strings.ToLower(menu.ShapeNames[c.Shape]), c.Iterations, c.Repeat, c.Alpha, c.Extension, c.OutputSize,
+		position, strings.ToLower(app.menuBuilder(lang).ShapeSetNames(c.Shapes)), c.Iterations, c.Repeat, c.Alpha, c.Extension, c.OutputSize,
 	)
 }
 
-func (app *application) getInputFromUser(
-	s sessions.Session,
-	min, max int,
-) (int, error) {
-	s.State = sessions.InInputDialog
-	app.sessions.Set(s.UserID, s, false)
-
-	err := app.bot.EditMessageText(s.UserID, s.MenuMessageID,
-		app.printer.Sprintf("Enter number between %#v and %#v:", min, max))
-	if err != nil {
-		return 0, err
-	}
-
-	for {
-		select {
-		case msg := <-s.Input:
-			// Delete message with user input
-			err := app.bot.DeleteMessage(msg.Chat.ID, msg.MessageID)
-			if err != nil {
-				return 0, err
-			}
-
-			userInput, err := strconv.Atoi(msg.Text)
-			// correct input
-			if err == nil && userInput >= min && userInput <= max {
-				s.State = sessions.InMenu
-				return userInput, nil
-			}
-
-			// incorrect input
-			err = app.bot.EditMessageText(
-				s.UserID, s.MenuMessageID,
-				app.printer.Sprintf("Incorrect value!\nEnter number between %#v and %#v:", min, max),
-			)
-			if err != nil {
-				if strings.Contains(err.Error(), "400") {
-					// 400 error: message is not modified
-					// and we don't care in this case
-					break
-				}
-				return 0, err
-			}
-		case <-s.QuitInput:
-			return 0, errSessionTerminated
-		}
-	}
-}
-
 func (app *application) showMenuView(
 	chatID, messageID int64,
 	view menu.View,
@@ -150,3 +107,259 @@ func mustCompileCached(pattern string) *regexp.Regexp {
 	}
 	return regex
 }
+
+// logEnqueued logs the operation that is added to the queue
+// in the format that is understood by restoreQueue.
+func (app *application) logEnqueued(op queue.Operation) {
+	c := op.Config
+	msg := fmt.Sprintf(enqueuedLogMessage+stopConditionsLogMessage, op.UserID, op.ImgPath, c.Iterations,
+		c.Shapes, c.Alpha, c.Repeat, c.OutputSize, c.Extension, c.TimeLimit, c.TargetScore, c.MinImprovement)
+	if c.Schedule != "" {
+		msg += fmt.Sprintf(scheduleLogMessage, c.Schedule)
+	}
+	if op.ResultID != "" {
+		msg += fmt.Sprintf(continueLogMessage, op.ResultID)
+	}
+
+	app.infoLog.Print(msg)
+}
+
+// getResult returns the result with the specified ID if it exists
+// and belongs to the user that pressed the button.
+func (app *application) getResult(q tg.CallbackQuery, id string) (results.Result, bool) {
+	r, err := app.results.Get(id)
+	if err != nil && !errors.Is(err, results.ErrNotFound) {
+		app.serverError(q.Message.Chat.ID, err)
+		return results.Result{}, false
+	}
+
+	if err != nil || r.UserID != q.From.ID {
+		err := app.bot.AnswerCallbackQuery(q.ID,
+			app.printer(app.userLang(q.From.ID)).Sprintf("This result is no longer available."))
+		if err != nil {
+			app.serverError(q.Message.Chat.ID, err)
+		}
+		return results.Result{}, false
+	}
+
+	return r, true
+}
+
+// renderResult renders the result with the config and sends it to the user.
+func (app *application) renderResult(q tg.CallbackQuery, r results.Result, c primitive.Config) {
+	lang := app.userLang(q.From.ID)
+	app.editResultKeyboard(q, app.menuBuilder(lang).NewResultKeyboard(r.ID))
+
+	start := time.Now()
+	outputPath := fmt.Sprintf("%s/%s_%d.%s", app.outDir, r.ID, c.OutputSize, c.FileExtension())
+	if err := c.Render(r.Scene, outputPath); err != nil {
+		app.serverError(q.Message.Chat.ID, err)
+		return
+	}
+	app.infoLog.Printf(renderedLogMessage, q.From.ID, r.ID, outputPath, time.Since(start).Seconds())
+
+	err := app.bot.SendDocument(q.Message.Chat.ID, outputPath,
+		app.printer(lang).Sprintf("Steps: %d", r.Scene.Steps), app.menuBuilder(lang).NewResultKeyboard(r.ID))
+	if err != nil {
+		app.serverError(q.Message.Chat.ID, err)
+		return
+	}
+	app.infoLog.Printf(sentLogMessage, q.From.ID, outputPath)
+}
+
+// runOperation creates the image of the operation and saves it in outputPath.
+// If there is a checkpoint of the operation, it is resumed from the checkpoint.
+// The state of the operation is saved to the checkpoint periodically.
+func (app *application) runOperation(op queue.Operation, outputPath string) (primitive.Scene, error) {
+	c := op.Config
+	var scene primitive.Scene // scene to continue, if any
+	var base int              // number of steps before the operation
+	var elapsed time.Duration // time spent before the restart
+	start := time.Now()
+
+	cp, err := app.results.GetCheckpoint()
+	if err != nil && !errors.Is(err, results.ErrNotFound) {
+		app.errorLog.Printf("Error loading checkpoint: %s", err)
+	}
+
+	switch {
+	case err == nil && cp.Operation == op:
+		scene, base, elapsed = cp.Scene, cp.Base, cp.Elapsed
+		c.Iterations -= cp.Scene.Steps - cp.Base
+		c.TimeLimit = remainingTime(c.TimeLimit, elapsed)
+		app.infoLog.Printf(resumedLogMessage, op.UserID, op.ImgPath, cp.Scene.Steps-cp.Base)
+	case op.ResultID != "":
+		r, err := app.results.Get(op.ResultID)
+		if err != nil {
+			return primitive.Scene{}, err
+		}
+		scene, base = r.Scene, r.Scene.Steps
+	}
+
+	var checkpoint []primitive.Checkpoint
+	if app.checkpointInterval > 0 {
+		checkpoint = append(checkpoint, primitive.Checkpoint{
+			Interval: app.checkpointInterval,
+			Save: func(s primitive.Scene) {
+				err := app.results.SaveCheckpoint(results.Checkpoint{
+					Operation: op,
+					Base:      base,
+					Scene:     s,
+					Elapsed:   elapsed + time.Since(start),
+					Created:   time.Now(),
+				})
+				if err != nil {
+					app.errorLog.Printf("Error saving checkpoint: %s", err)
+				}
+			},
+		})
+	}
+
+	if scene.Version == 0 {
+		return c.Create(op.ImgPath, outputPath, checkpoint...)
+	}
+	return c.Continue(op.ImgPath, scene, outputPath, checkpoint...)
+}
+
+// remainingTime returns the time limit of the resumed operation that has
+// already run for elapsed. Zero limit means there is no limit, so the
+// exhausted limit is replaced with the shortest one, which stops the
+// operation after the first step.
+func remainingTime(limit, elapsed time.Duration) time.Duration {
+	if limit == 0 {
+		return 0
+	}
+	if elapsed >= limit {
+		return time.Nanosecond
+	}
+	return limit - elapsed
+}
+
+// deleteCheckpoint removes the checkpoint of the finished or failed operation.
+func (app *application) deleteCheckpoint() {
+	if err := app.results.DeleteCheckpoint(); err != nil {
+		app.errorLog.Printf("Error deleting checkpoint: %s", err)
+	}
+}
+
+// applyConfig replaces the config of the session and rebuilds its menu.
+// The values that exceed the limits set by the operator are clamped.
+func (app *application) applyConfig(s sessions.Session, c primitive.Config) sessions.Session {
+	s.Config = app.clampConfig(c)
+	s.Menu = app.menuBuilder(s.Lang).New(s.Config)
+	app.sessions.Set(s)
+
+	return s
+}
+
+// clampConfig returns the config with the values that
+// exceed the limits set by the operator clamped.
+func (app *application) clampConfig(c primitive.Config) primitive.Config {
+	if c.Iterations > app.maxIter {
+		c.Iterations = app.maxIter
+	}
+	if c.OutputSize > app.maxSize {
+		c.OutputSize = app.maxSize
+	}
+	return c
+}
+
+// presetsView returns the view with the presets of the user of the session.
+func (app *application) presetsView(s sessions.Session) (menu.View, error) {
+	list, err := app.presets.List(s.UserID)
+	if err != nil {
+		return menu.View{}, err
+	}
+
+	names := make([]string, len(list))
+	for i, p := range list {
+		names[i] = p.Name
+	}
+	return app.menuBuilder(s.Lang).NewPresetsView(names), nil
+}
+
+func (app *application) editResultKeyboard(q tg.CallbackQuery, keyboard tg.InlineKeyboardMarkup) {
+	err := app.bot.EditMessageReplyMarkup(q.Message.Chat.ID, q.Message.MessageID, keyboard)
+	if err != nil {
+		if strings.Contains(err.Error(), "400") {
+			// 400 error: message is not modified
+			// and we don't care in this case
+			return
+		}
+		app.serverError(q.Message.Chat.ID, err)
+	}
+}
+
+// enqueue adds the operation to the queue and remembers its config as
+// the last used one. It returns position of the operation in the queue.
+// If the user can't add more operations, the returned bool is false.
+func (app *application) enqueue(op queue.Operation) (int, bool) {
+	if app.queue.GetNumOperations(op.UserID) >= app.operationsLimitOf(op.UserID) {
+		return 0, false
+	}
+
+	app.logEnqueued(op)
+	pos := app.queue.Enqueue(op)
+
+	// the new sessions will start with these settings
+	if err := app.prefs.Remember(op.UserID, op.Config); err != nil {
+		app.errorLog.Printf("Error saving preferences: %s", err)
+	}
+
+	return pos, true
+}
+
+// parseParams applies parameters from the text to the config using the
+// limits set by the operator. If the text is incorrect, the returned
+// error will be of type *params.Error.
+func (app *application) parseParams(text string, c primitive.Config) (primitive.Config, error) {
+	return params.Parse(text, app.clampConfig(c), params.Limits{
+		MaxIter: app.maxIter,
+		MaxSize: app.maxSize,
+		MaxTime: app.maxTime,
+	})
+}
+
+// paramsErrorMessage returns description of the error in the language lang.
+func (app *application) paramsErrorMessage(lang string, e *params.Error) string {
+	p := app.printer(lang)
+	switch e.Kind {
+	case params.ErrSyntax:
+		return p.Sprintf("Incorrect parameter '%s'. Parameters must be in the form key=value, for example: steps=800.", e.Value)
+	case params.ErrUnknownKey:
+		return p.Sprintf("Unknown parameter '%s'.", e.Key)
+	case params.ErrOutOfRange:
+		return p.Sprintf("The value of the parameter '%s' must be from %s to %s.", e.Key, e.Min, e.Max)
+	default:
+		return p.Sprintf("Incorrect value '%s' of the parameter '%s'.", e.Value, e.Key)
+	}
+}
+
+// addSession adds the new session. If the user has
+// too many sessions, menu of the oldest one is deleted.
+func (app *application) addSession(s sessions.Session) {
+	evicted, ok := app.sessions.Add(s)
+	if !ok {
+		return
+	}
+
+	err := app.bot.DeleteMessage(evicted.UserID, evicted.MenuMessageID)
+	if err != nil {
+		app.serverError(evicted.UserID, err)
+	}
+}
+
+// expireMenu replaces menu of the expired session with the message
+// about expiration. The menu can be reopened while the input image exists.
+func (app *application) expireMenu(s sessions.Session) {
+	var imgName string
+	if _, err := os.Stat(s.ImgPath); err == nil {
+		imgName = filepath.Base(s.ImgPath)
+	}
+
+	view := app.menuBuilder(s.Lang).NewExpiredView(imgName)
+	err := app.bot.EditMessageText(s.UserID, s.MenuMessageID, view.Text, view.Keyboard)
+	if err != nil {
+		app.errorLog.Printf("Error marking menu as expired: %s", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lazy-void/primitive-bot/pkg/menu"
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+	"github.com/lazy-void/primitive-bot/pkg/sessions"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// scheduleField is the field of the dialog that asks to enter the
+// schedule. Dialogs of the options use the names of the options.
+const scheduleField = "schedule"
+
+// openDialog switches the session to the input dialog and shows its prompt.
+func (app *application) openDialog(s sessions.Session, d sessions.Dialog) {
+	s.OpenDialog(d)
+	app.sessions.Set(s)
+
+	app.showMenuView(s.UserID, s.MenuMessageID, app.menuBuilder(s.Lang).NewInputView(app.inputPrompt(s.Lang, d, false)))
+}
+
+// handleInput handles the message sent by the user while the session is
+// in the input dialog. If the value is accepted, the dialog is closed.
+// Otherwise it stays open and the prompt explains what is wrong.
+func (app *application) handleInput(s sessions.Session, m tg.Message) {
+	// Delete message with user input
+	err := app.bot.DeleteMessage(m.Chat.ID, m.MessageID)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return
+	}
+
+	d := s.Dialog
+	v, err := d.Parse(m.Text)
+	if err == nil {
+		err = app.applyInput(&s, d.Field, v)
+	}
+	if errors.Is(err, sessions.ErrIncorrectInput) {
+		app.showMenuView(s.UserID, s.MenuMessageID, app.menuBuilder(s.Lang).NewInputView(app.inputPrompt(s.Lang, d, true)))
+		return
+	} else if err != nil {
+		app.serverError(s.UserID, err)
+		return
+	}
+
+	s.CloseDialog()
+	app.sessions.Set(s)
+
+	app.showMenuView(s.UserID, s.MenuMessageID, app.dialogView(s, d.Field))
+}
+
+func (app *application) handleInputCancel(s sessions.Session) {
+	field := s.Dialog.Field
+	s.CloseDialog()
+	app.sessions.Set(s)
+
+	app.showMenuView(s.UserID, s.MenuMessageID, app.dialogView(s, field))
+}
+
+// applyInput sets the value to the parameter and updates the menu. It
+// returns error if the value is incorrect for the parameter.
+func (app *application) applyInput(s *sessions.Session, field string, v sessions.Value) error {
+	if field == scheduleField {
+		sc, err := primitive.ParseSchedule(v.Text)
+		if err != nil || len(sc) == 0 {
+			return sessions.ErrIncorrectInput
+		}
+		s.Config.Schedule = sc.String()
+		s.Menu.ScheduleView = app.menuBuilder(s.Lang).NewScheduleView(s.Config.Schedule)
+		return nil
+	}
+
+	o, ok := app.menuBuilder(s.Lang).FindOption(field)
+	if !ok {
+		return fmt.Errorf("unknown input field %q", field)
+	}
+	c, ok := o.Apply(s.Config, strconv.Itoa(v.Int))
+	if !ok {
+		return sessions.ErrIncorrectInput
+	}
+	s.Config = c
+
+	return nil
+}
+
+// dialogView returns the view that is shown after the dialog is closed.
+func (app *application) dialogView(s sessions.Session, field string) menu.View {
+	if field == scheduleField {
+		return s.Menu.ScheduleView
+	}
+	b := app.menuBuilder(s.Lang)
+	if o, ok := b.FindOption(field); ok {
+		return o.View(s.Config)
+	}
+	return b.NewRootView(s.Config)
+}
+
+// inputPrompt returns prompt of the dialog in the language lang. If retry
+// is true, the prompt also says that the previous value was incorrect.
+func (app *application) inputPrompt(lang string, d sessions.Dialog, retry bool) string {
+	p := app.printer(lang)
+	switch {
+	case d.Field == scheduleField && retry:
+		return p.Sprintf("Incorrect schedule!\nEnter the schedule:")
+	case d.Field == scheduleField:
+		return p.Sprintf("Enter the schedule:")
+	}
+
+	min, max := int(d.Min), int(d.Max)
+	options := strings.Join(d.Options, ", ")
+	switch d.Kind {
+	case sessions.IntInput:
+		if retry {
+			return p.Sprintf("Incorrect value!\nEnter number between %#v and %#v:", min, max)
+		}
+		return p.Sprintf("Enter number between %#v and %#v:", min, max)
+	case sessions.FloatInput:
+		if retry {
+			return p.Sprintf("Incorrect value!\nEnter a number between %g and %g:", d.Min, d.Max)
+		}
+		return p.Sprintf("Enter a number between %g and %g:", d.Min, d.Max)
+	case sessions.ColorInput:
+		if retry {
+			return p.Sprintf("Incorrect color!\nEnter the color in the format #rrggbb:")
+		}
+		return p.Sprintf("Enter the color in the format #rrggbb:")
+	case sessions.EnumInput:
+		if retry {
+			return p.Sprintf("Incorrect value!\nEnter one of the values: %s", options)
+		}
+		return p.Sprintf("Enter one of the values: %s", options)
+	default:
+		if retry {
+			return p.Sprintf("The text can't be empty!\nEnter the text:")
+		}
+		return p.Sprintf("Enter the text:")
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/lazy-void/primitive-bot/pkg/locales"
+	"github.com/lazy-void/primitive-bot/pkg/menu"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// translations contains the printers and the menus in
+// the languages of the catalogs that are loaded.
+type translations struct {
+	languages []language.Tag
+	printers  map[string]*message.Printer
+	menus     map[string]*menu.Builder
+}
+
+// loadTranslations loads the catalogs and replaces the translations
+// with them. The catalog of the default language of the bot is required.
+// The messages that aren't translated in the catalogs are logged.
+func (app *application) loadTranslations() error {
+	c, err := locales.Load(app.locales)
+	if err != nil {
+		return err
+	}
+
+	t := &translations{
+		languages: c.Languages,
+		printers:  make(map[string]*message.Printer, len(c.Languages)),
+		menus:     make(map[string]*menu.Builder, len(c.Languages)),
+	}
+	limits := menu.Limits{MaxIter: app.maxIter, MaxSize: app.maxSize, MaxTime: app.maxTime}
+	for _, tag := range c.Languages {
+		p := c.Printer(tag)
+		t.printers[tag.String()] = p
+		t.menus[tag.String()] = menu.NewBuilder(tag, p, limits, app.styles)
+
+		if ids := c.Missing[tag]; len(ids) > 0 {
+			app.errorLog.Printf("Catalog %s: %d messages aren't translated: %s",
+				tag, len(ids), strings.Join(ids, "; "))
+		}
+	}
+	if _, ok := t.menus[app.lang]; !ok {
+		return fmt.Errorf("there isn't a catalog of the language %q", app.lang)
+	}
+
+	app.translationsMu.Lock()
+	defer app.translationsMu.Unlock()
+	app.translations = t
+	return nil
+}
+
+// reloadTranslations reloads the catalogs when the bot receives SIGHUP. If
+// the catalogs can't be loaded, the translations that are loaded are kept.
+func (app *application) reloadTranslations() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	for range c {
+		if err := app.loadTranslations(); err != nil {
+			app.errorLog.Printf("Error reloading translations: %s", err)
+			continue
+		}
+		app.infoLog.Printf("Translations are reloaded")
+	}
+}
+
+// currentTranslations returns the translations that are loaded.
+func (app *application) currentTranslations() *translations {
+	app.translationsMu.RLock()
+	defer app.translationsMu.RUnlock()
+	return app.translations
+}
+
+// supportedLang reports whether there is a catalog of the language.
+func (app *application) supportedLang(lang string) bool {
+	_, ok := app.currentTranslations().menus[lang]
+	return ok
+}
+
+// detectLang remembers the language of the authorized user who sent the
+// update. The language chosen with /language takes precedence over the
+// language of the user's Telegram app.
+func (app *application) detectLang(u tg.User) {
+	lang, err := app.prefs.Lang(u.ID)
+	if err != nil {
+		app.errorLog.Printf("Error loading language: %s", err)
+	}
+	if !app.supportedLang(lang) {
+		lang = app.matchLang(u.LanguageCode)
+	}
+
+	app.langsMu.Lock()
+	defer app.langsMu.Unlock()
+	app.langs[u.ID] = lang
+}
+
+// userLang returns the language of the user.
+func (app *application) userLang(userID int64) string {
+	app.langsMu.Lock()
+	lang, ok := app.langs[userID]
+	app.langsMu.Unlock()
+	if ok {
+		return lang
+	}
+
+	// the user hasn't sent updates since the bot started,
+	// so only the language chosen with /language is known
+	lang, err := app.prefs.Lang(userID)
+	if err != nil {
+		app.errorLog.Printf("Error loading language: %s", err)
+	}
+	if !app.supportedLang(lang) {
+		return app.lang
+	}
+	return lang
+}
+
+// matchLang returns the supported language that matches the IETF
+// language tag. If there isn't one, the language of the bot is returned.
+func (app *application) matchLang(code string) string {
+	tag, err := language.Parse(code)
+	if err != nil {
+		return app.lang
+	}
+
+	languages := app.currentTranslations().languages
+	_, i, confidence := language.NewMatcher(languages).Match(tag)
+	if confidence == language.No {
+		return app.lang
+	}
+	return languages[i].String()
+}
+
+// printer returns the printer for the language lang. If
+// the language isn't supported, the language of the bot is used.
+func (app *application) printer(lang string) *message.Printer {
+	t := app.currentTranslations()
+	if p, ok := t.printers[lang]; ok {
+		return p
+	}
+	return t.printers[app.lang]
+}
+
+// menuBuilder returns the builder of the menu in the language lang.
+// If the language isn't supported, the language of the bot is used.
+func (app *application) menuBuilder(lang string) *menu.Builder {
+	t := app.currentTranslations()
+	if b, ok := t.menus[lang]; ok {
+		return b
+	}
+	return t.menus[app.lang]
+}
+
+// languageView returns the view for selecting the language
+// with the language lang selected.
+func (app *application) languageView(lang string) menu.View {
+	t := app.currentTranslations()
+	list := make([]*menu.Builder, len(t.languages))
+	for i, tag := range t.languages {
+		list[i] = t.menus[tag.String()]
+	}
+	b, ok := t.menus[lang]
+	if !ok {
+		b = t.menus[app.lang]
+	}
+	return b.NewLanguageView(list)
+}
+
+func (app *application) handleLanguageCommand(m tg.Message) {
+	view := app.languageView(app.userLang(m.From.ID))
+	_, err := app.bot.SendMessage(m.Chat.ID, view.Text, view.Keyboard)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+	}
+}
+
+func (app *application) handleLanguageButton(q tg.CallbackQuery, lang string) {
+	if !app.supportedLang(lang) {
+		return
+	}
+
+	if err := app.prefs.SetLang(q.From.ID, lang); err != nil {
+		app.serverError(q.From.ID, err)
+		return
+	}
+	app.langsMu.Lock()
+	app.langs[q.From.ID] = lang
+	app.langsMu.Unlock()
+
+	app.showMenuView(q.From.ID, q.Message.MessageID, app.languageView(lang))
+	err := app.bot.AnswerCallbackQuery(q.ID, app.printer(lang).Sprintf("The language is changed."))
+	if err != nil {
+		app.serverError(q.From.ID, err)
+	}
+}
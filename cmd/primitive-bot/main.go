@@ -1,63 +1,115 @@
 package main
 
-//go:generate gotext update -out=catalog.go -lang=en,ru
+// The languages are taken from the catalogs in the directory locales,
+// so a language is added by creating locales/<tag>/messages.gotext.json.
+//go:generate sh -c "gotext update -lang=$(ls locales | paste -sd , -)"
 
 import (
 	"bufio"
+	"embed"
 	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/lazy-void/primitive-bot/pkg/menu"
-
-	"golang.org/x/text/language"
-
-	"golang.org/x/text/message"
-
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
 
+	"github.com/lazy-void/primitive-bot/pkg/config"
+	"github.com/lazy-void/primitive-bot/pkg/mailbox"
+	"github.com/lazy-void/primitive-bot/pkg/prefs"
+	"github.com/lazy-void/primitive-bot/pkg/presets"
 	"github.com/lazy-void/primitive-bot/pkg/queue"
+	"github.com/lazy-void/primitive-bot/pkg/results"
 	"github.com/lazy-void/primitive-bot/pkg/sessions"
+	"github.com/lazy-void/primitive-bot/pkg/styles"
 	"github.com/lazy-void/primitive-bot/pkg/tg"
+	"github.com/lazy-void/primitive-bot/pkg/users"
 )
 
+// envPrefix is the prefix of the environment variables that set the flags.
+const envPrefix = "PRIMITIVE_BOT_"
+
 var (
+	configPath      string
 	token           string
+	tokenFile       string
 	inDir           string
 	outDir          string
 	logPath         string
 	operationsLimit int
 	maxIter         int
 	maxSize         int
+	maxTime         time.Duration
 	workers         int
 	timeout         time.Duration
-	lang            language.Tag
+	retention       time.Duration
+	checkpoint      time.Duration
+	stylesDir       string
+	presetsLimit    int
+	sessionsLimit   int
+	mailboxSize     int
+	lang            string
+	localesDir      string
+	admins          []int64
+	auditPath       string
+	access          string
 )
 
+// builtinLocales contains the catalogs that are used
+// if the directory with the catalogs isn't specified.
+//
+//go:embed locales/*/messages.gotext.json
+var builtinLocales embed.FS
+
 type application struct {
-	infoLog         *log.Logger
-	errorLog        *log.Logger
-	printer         *message.Printer
-	inDir           string
-	outDir          string
-	operationsLimit int
-	maxIter         int
-	maxSize         int
-	workers         int
-	bot             *tg.Bot
-	sessions        *sessions.ActiveSessions
-	queue           *queue.Queue
+	infoLog            *log.Logger
+	errorLog           *log.Logger
+	auditLog           *log.Logger
+	started            time.Time
+	lang               string
+	locales            fs.FS
+	translations       *translations
+	translationsMu     sync.RWMutex
+	langs              map[int64]string
+	langsMu            sync.Mutex
+	admins             map[int64]bool
+	access             string
+	users              *users.Store
+	inDir              string
+	outDir             string
+	operationsLimit    int
+	maxIter            int
+	maxSize            int
+	maxTime            time.Duration
+	checkpointInterval time.Duration
+	workers            int
+	bot                *tg.Bot
+	sessions           *sessions.ActiveSessions
+	queue              *queue.Queue
+	results            *results.Store
+	styles             []styles.Style
+	presets            *presets.Store
+	presetsLimit       int
+	prefs              *prefs.Store
+	mailboxes          *mailbox.Mailboxes
 }
 
 func init() {
+	flag.StringVar(&configPath, "config", "",
+		"Path to the configuration file in YAML format. Its keys are the names of the flags. "+
+			"The flags on the command line and the "+envPrefix+"* environment variables take precedence over it.")
 	flag.StringVar(&token, "token", "", "The token for the Telegram Bot.")
+	flag.StringVar(&tokenFile, "token-file", "",
+		"Path to the file with the token for the Telegram Bot, so the token isn't shown in the process list.")
 	flag.StringVar(&inDir, "i", "inputs",
 		"Path to the directory where user-supplied images are stored.")
 	flag.StringVar(&outDir, "o", "outputs",
@@ -70,14 +122,46 @@ func init() {
 		"The number of operations that the user can add to the queue.")
 	flag.IntVar(&maxIter, "steps", 2000, "The max value of steps that the user can specify.")
 	flag.IntVar(&maxSize, "size", 3840, "The max value of image size that the user can specify.")
+	flag.DurationVar(&maxTime, "time", 0,
+		"The max time that can be spent on one operation. Zero means no limit.")
 	flag.DurationVar(&timeout, "timeout", 30*time.Minute,
 		"The period of time that a session can be inactive before it's terminated.")
-	flag.Func("lang", `Language of the bot (en, ru). (default "en")`, func(s string) error {
-		if s != "en" && s != "ru" {
-			return errors.New("incorrect language")
-		}
+	flag.DurationVar(&retention, "keep", 24*time.Hour,
+		"The period of time during which the results can be rendered again or continued. Zero means forever.")
+	flag.DurationVar(&checkpoint, "checkpoint", time.Minute,
+		"How often the progress of the current operation is saved, so it can be resumed after restart. Zero disables checkpoints.")
+	flag.IntVar(&sessionsLimit, "sessions", 3,
+		"The number of images that the user can configure at once. Zero means no limit.")
+	flag.IntVar(&mailboxSize, "updates", 10,
+		"The number of updates from the user that can wait to be processed. Excess updates are dropped.")
+	flag.IntVar(&presetsLimit, "presets", 10,
+		"The number of presets that the user can save. Zero means no limit.")
+	flag.StringVar(&stylesDir, "styles", "",
+		"Path to the directory with additional style presets in JSON format.")
+	flag.StringVar(&lang, "lang", "en",
+		"Default language of the bot. There must be a catalog of this language.")
+	flag.StringVar(&localesDir, "locales", "",
+		"Path to the directory with the translation catalogs in gotext JSON format, e.g. ru/messages.gotext.json. "+
+			"They are reloaded on SIGHUP. The built-in catalogs are used by default.")
+	flag.StringVar(&access, "access", openAccess,
+		"Who can use the bot: "+openAccess+" (everyone except the banned users) or "+
+			privateAccess+" (only the users allowed by the admins with /allow).")
+	flag.StringVar(&auditPath, "audit", "",
+		"Path to the file where the actions of the admins are logged. They are logged to the standard output by default.")
+	flag.Func("admins", "Comma-separated IDs of the Telegram users that administer the bot.", func(s string) error {
+		admins = nil
+		for _, field := range strings.Split(s, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
 
-		lang = language.MustParse(s)
+			id, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return fmt.Errorf("incorrect user ID %q", field)
+			}
+			admins = append(admins, id)
+		}
 		return nil
 	})
 }
@@ -85,11 +169,16 @@ func init() {
 func main() {
 	flag.Parse()
 
-	if token == "" {
-		log.Fatal("You need to provide token for the Telegram Bot!")
+	// the flags that aren't on the command line are
+	// taken from the environment and the configuration file
+	if configPath == "" {
+		configPath = os.Getenv(config.EnvName(envPrefix, "config"))
 	}
-	if lang.String() == "und" {
-		lang = language.MustParse("en")
+	if err := config.Apply(flag.CommandLine, envPrefix, configPath, "config"); err != nil {
+		log.Fatalf("Error reading configuration: %v", err)
+	}
+	if err := validateSettings(); err != nil {
+		log.Fatalf("Error in configuration: %v", err)
 	}
 
 	// restore queue if needed
@@ -112,29 +201,141 @@ func main() {
 	infoLog := log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
 	errorLog := log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)
 
-	// initialize localization
-	printer := message.NewPrinter(lang)
-	menu.InitText(printer)
+	// the actions of the admins are kept apart from the other logs
+	auditLog := log.New(os.Stdout, "AUDIT\t", log.Ldate|log.Ltime)
+	if auditPath != "" {
+		f, err := os.OpenFile(filepath.Clean(auditPath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatalf("Error opening audit log: %v", err)
+		}
+		defer f.Close()
+		auditLog = log.New(f, "", log.Ldate|log.Ltime)
+	}
+
+	userStore, err := users.NewStore(filepath.Join(outDir, "users.json"))
+	if err != nil {
+		log.Fatalf("Error loading users: %v", err)
+	}
+
+	// load style presets
+	styleList, err := styles.Load(stylesDir)
+	if err != nil {
+		log.Fatalf("Error loading styles: %v", err)
+	}
+
+	// the catalogs are read from the directory, so
+	// the translations can be changed without rebuilding
+	locales, err := fs.Sub(builtinLocales, "locales")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if localesDir != "" {
+		locales = os.DirFS(localesDir)
+	}
+
+	// sessions are kept with the results, so the menus keep working after restart
+	sessionStore := sessions.NewFileStore(outDir, workers)
 
 	app := application{
-		infoLog:         infoLog,
-		errorLog:        errorLog,
-		printer:         printer,
-		inDir:           inDir,
-		outDir:          outDir,
-		operationsLimit: operationsLimit,
-		maxIter:         maxIter,
-		maxSize:         maxSize,
-		workers:         workers,
-		bot:             &tg.Bot{Token: token},
-		sessions:        sessions.NewActiveSessions(timeout, 5*time.Minute, errorLog),
-		queue:           q,
+		infoLog:            infoLog,
+		errorLog:           errorLog,
+		auditLog:           auditLog,
+		started:            time.Now(),
+		lang:               lang,
+		locales:            locales,
+		langs:              make(map[int64]string),
+		admins:             make(map[int64]bool, len(admins)),
+		access:             access,
+		users:              userStore,
+		inDir:              inDir,
+		outDir:             outDir,
+		operationsLimit:    operationsLimit,
+		maxIter:            maxIter,
+		maxSize:            maxSize,
+		maxTime:            maxTime,
+		checkpointInterval: checkpoint,
+		workers:            workers,
+		bot:                &tg.Bot{Token: token},
+		sessions:           sessions.NewActiveSessions(timeout, 5*time.Minute, sessionsLimit, sessionStore, errorLog),
+		queue:              q,
+		results:            results.NewStore(outDir, workers, retention, time.Hour, errorLog),
+		styles:             styleList,
+		presets:            presets.NewStore(outDir, workers, presetsLimit),
+		presetsLimit:       presetsLimit,
+		prefs:              prefs.NewStore(outDir, workers),
+		mailboxes:          mailbox.New(mailboxSize),
+	}
+
+	for _, id := range admins {
+		app.admins[id] = true
+	}
+
+	// each user gets the messages and the menus in their language
+	if err := app.loadTranslations(); err != nil {
+		log.Fatalf("Error loading translations: %v", err)
+	}
+	if localesDir != "" {
+		go app.reloadTranslations()
+	}
+
+	app.sessions.OnExpire(app.expireMenu)
+
+	if err := app.sessions.Restore(); err != nil {
+		log.Fatalf("Error restoring sessions: %v", err)
 	}
 
 	infoLog.Printf("Starting to listen for the updates...")
 	app.listenAndServe()
 }
 
+// validateSettings checks the values of the flags and reads
+// the token from the file if it's specified.
+func validateSettings() error {
+	if tokenFile != "" {
+		if token != "" {
+			return errors.New("the token and the token file can't be specified at once")
+		}
+
+		data, err := os.ReadFile(filepath.Clean(tokenFile))
+		if err != nil {
+			return err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return errors.New("you need to provide token for the Telegram Bot")
+	}
+
+	if access != openAccess && access != privateAccess {
+		return fmt.Errorf("-access must be %s or %s", openAccess, privateAccess)
+	}
+
+	positive := map[string]int{
+		"w": workers, "limit": operationsLimit, "steps": maxIter, "size": maxSize, "updates": mailboxSize,
+	}
+	for name, v := range positive {
+		if v < 1 {
+			return fmt.Errorf("-%s must be positive", name)
+		}
+	}
+	nonNegative := map[string]int{"sessions": sessionsLimit, "presets": presetsLimit}
+	for name, v := range nonNegative {
+		if v < 0 {
+			return fmt.Errorf("-%s can't be negative", name)
+		}
+	}
+	durations := map[string]time.Duration{
+		"time": maxTime, "timeout": timeout, "keep": retention, "checkpoint": checkpoint,
+	}
+	for name, d := range durations {
+		if d < 0 {
+			return fmt.Errorf("-%s can't be negative", name)
+		}
+	}
+
+	return nil
+}
+
 func restoreQueue(logPath string, q *queue.Queue, workers int) (err error) {
 	f, err := os.Open(filepath.Clean(logPath))
 	if err != nil {
@@ -157,18 +358,57 @@ func restoreQueue(logPath string, q *queue.Queue, workers int) (err error) {
 		if strings.HasPrefix(msg, "Enqueued:") {
 			op := queue.Operation{Config: primitive.New(workers)}
 			_, err := fmt.Sscanf(msg, enqueuedLogMessage, &op.UserID, &op.ImgPath,
-				&op.Config.Iterations, &op.Config.Shape, &op.Config.Alpha,
+				&op.Config.Iterations, &op.Config.Shapes, &op.Config.Alpha,
 				&op.Config.Repeat, &op.Config.OutputSize, &op.Config.Extension)
 			if err != nil {
 				return err
 			}
 
+			// stop conditions were added to the log message later
+			if i := strings.Index(msg, " | stop:"); i >= 0 {
+				var timeLimit string
+				_, err := fmt.Sscanf(msg[i:], stopConditionsLogMessage, &timeLimit,
+					&op.Config.TargetScore, &op.Config.MinImprovement)
+				if err != nil {
+					return err
+				}
+
+				op.Config.TimeLimit, err = time.ParseDuration(timeLimit)
+				if err != nil {
+					return err
+				}
+			}
+
+			if i := strings.Index(msg, " | schedule:"); i >= 0 {
+				_, err := fmt.Sscanf(msg[i:], scheduleLogMessage, &op.Config.Schedule)
+				if err != nil {
+					return err
+				}
+			}
+
+			if i := strings.Index(msg, " | continue:"); i >= 0 {
+				_, err := fmt.Sscanf(msg[i:], continueLogMessage, &op.ResultID)
+				if err != nil {
+					return err
+				}
+			}
+
 			q.Enqueue(op)
 			continue
 		}
 
-		if strings.HasPrefix(msg, "Sent:") {
+		if strings.HasPrefix(msg, "Sent:") || strings.HasPrefix(msg, "Skipped:") {
 			q.Dequeue()
+			continue
+		}
+
+		if strings.HasPrefix(msg, "Removed:") {
+			var userID int64
+			var imgPath string
+			if _, err := fmt.Sscanf(msg, removedLogMessage, &userID, &imgPath); err != nil {
+				return err
+			}
+			q.Remove(userID, imgPath)
 		}
 	}
 
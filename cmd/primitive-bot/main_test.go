@@ -1,12 +1,22 @@
 package main
 
 import (
+	"io"
+	"io/fs"
+	"log"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
+	"golang.org/x/text/language"
+
+	"github.com/lazy-void/primitive-bot/pkg/locales"
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
 	"github.com/lazy-void/primitive-bot/pkg/queue"
+	"github.com/lazy-void/primitive-bot/pkg/sessions"
+	"github.com/lazy-void/primitive-bot/pkg/users"
 )
 
 func TestRestoreQueue(t *testing.T) {
@@ -68,7 +78,7 @@ INFO	2021/05/23 16:49:20 Sent: user id 295434263 | output outputs/295434263_1621
 					Config: func() primitive.Config {
 						c := primitive.New(workers)
 						c.Iterations = 20
-						c.Shape = 2
+						c.Shapes = primitive.NewShapeSet(primitive.ShapeRectangle)
 						return c
 					}(),
 				},
@@ -78,7 +88,85 @@ INFO	2021/05/23 16:49:20 Sent: user id 295434263 | output outputs/295434263_1621
 					Config: func() primitive.Config {
 						c := primitive.New(workers)
 						c.Iterations = 30
-						c.Shape = 1
+						c.Shapes = primitive.NewShapeSet(primitive.ShapeTriangle)
+						return c
+					}(),
+				},
+			},
+		},
+		{
+			name: "Operation with stop conditions",
+			logData: `
+INFO	2021/05/23 16:00:42 Starting to listen for updates...
+INFO	2021/05/23 16:00:49 Callback Query: data '/create' from the user 'Kir' with the ID '295434263'
+INFO	2021/05/23 16:00:49 Enqueued: user id 295434263 | input inputs/AQADntiNoi4AAwSIAgAB.jpg | iterations=200, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg | stop: time=5m0s score=95 improvement=0.05
+`,
+			operations: []queue.Operation{
+				{
+					UserID:  295434263,
+					ImgPath: "inputs/AQADntiNoi4AAwSIAgAB.jpg",
+					Config: func() primitive.Config {
+						c := primitive.New(workers)
+						c.TimeLimit = 5 * time.Minute
+						c.TargetScore = 95
+						c.MinImprovement = 0.05
+						return c
+					}(),
+				},
+			},
+		},
+		{
+			name: "Continuation of the result",
+			logData: `
+INFO	2021/05/23 16:00:42 Starting to listen for updates...
+INFO	2021/05/23 16:00:49 Callback Query: data '/res/295434263_1621778062/more/100' from the user 'Kir' with the ID '295434263'
+INFO	2021/05/23 16:00:49 Enqueued: user id 295434263 | input inputs/AQADntiNoi4AAwSIAgAB.jpg | iterations=100, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg | stop: time=0s score=0 improvement=0 | continue: 295434263_1621778062
+`,
+			operations: []queue.Operation{
+				{
+					UserID:   295434263,
+					ImgPath:  "inputs/AQADntiNoi4AAwSIAgAB.jpg",
+					ResultID: "295434263_1621778062",
+					Config: func() primitive.Config {
+						c := primitive.New(workers)
+						c.Iterations = 100
+						return c
+					}(),
+				},
+			},
+		},
+		{
+			name: "Operation with several shapes",
+			logData: `
+INFO	2021/05/23 16:00:42 Starting to listen for updates...
+INFO	2021/05/23 16:00:49 Enqueued: user id 295434263 | input inputs/AQADntiNoi4AAwSIAgAB.jpg | iterations=200, shape=1+3+6, alpha=128, repeat=1, resolution=1280, extension=jpg | stop: time=0s score=0 improvement=0
+`,
+			operations: []queue.Operation{
+				{
+					UserID:  295434263,
+					ImgPath: "inputs/AQADntiNoi4AAwSIAgAB.jpg",
+					Config: func() primitive.Config {
+						c := primitive.New(workers)
+						c.Shapes = primitive.NewShapeSet(primitive.ShapeTriangle, primitive.ShapeEllipse, primitive.ShapeBezier)
+						return c
+					}(),
+				},
+			},
+		},
+		{
+			name: "Operation with schedule",
+			logData: `
+INFO	2021/05/23 16:00:42 Starting to listen for updates...
+INFO	2021/05/23 16:00:49 Enqueued: user id 295434263 | input inputs/AQADntiNoi4AAwSIAgAB.jpg | iterations=200, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg | stop: time=0s score=0 improvement=0 | schedule: 1-100:a=255,s=1;101-:a=64,s=3 | continue: 295434263_1621778062
+`,
+			operations: []queue.Operation{
+				{
+					UserID:   295434263,
+					ImgPath:  "inputs/AQADntiNoi4AAwSIAgAB.jpg",
+					ResultID: "295434263_1621778062",
+					Config: func() primitive.Config {
+						c := primitive.New(workers)
+						c.Schedule = "1-100:a=255,s=1;101-:a=64,s=3"
 						return c
 					}(),
 				},
@@ -125,6 +213,43 @@ INFO	2021/05/23 16:54:33 Sent: user id 295434263 | output outputs/295434263_1621
 `,
 			operations: []queue.Operation{},
 		},
+		{
+			name: "Operation dropped by the admin",
+			logData: `
+INFO	2021/05/23 17:10:00 Starting to listen for updates...
+INFO	2021/05/23 17:10:01 Enqueued: user id 295434263 | input inputs/AQADntiNoi4AAwSIAgAB.jpg | iterations=10, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg
+INFO	2021/05/23 17:10:01 Creating: user id 295434263 | input inputs/AQADntiNoi4AAwSIAgAB.jpg | output outputs/295434263_1621779001.jpg | iterations=10, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg
+INFO	2021/05/23 17:10:02 Enqueued: user id 1 | input inputs/AQADBBBBBBBBBBBBBBBB.jpg | iterations=20, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg
+INFO	2021/05/23 17:10:03 Enqueued: user id 2 | input inputs/AQADCCCCCCCCCCCCCCCC.jpg | iterations=30, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg
+INFO	2021/05/23 17:10:03 Enqueued: user id 1 | input inputs/AQADBBBBBBBBBBBBBBBB.jpg | iterations=40, shape=0, alpha=128, repeat=1, resolution=1280, extension=jpg | continue: 1_1621779000
+INFO	2021/05/23 17:10:04 Dropped: user id 2 | too many unprocessed updates
+INFO	2021/05/23 17:10:05 Removed: user id 1 | input inputs/AQADBBBBBBBBBBBBBBBB.jpg
+INFO	2021/05/23 17:10:06 Finished: user id 295434263 | input inputs/AQADntiNoi4AAwSIAgAB.jpg | output outputs/295434263_1621779001.jpg | 1.0 seconds
+INFO	2021/05/23 17:10:06 Sent: user id 295434263 | output outputs/295434263_1621779001.jpg
+`,
+			// the earliest waiting operation with the input is removed
+			operations: []queue.Operation{
+				{
+					UserID:  2,
+					ImgPath: "inputs/AQADCCCCCCCCCCCCCCCC.jpg",
+					Config: func() primitive.Config {
+						c := primitive.New(workers)
+						c.Iterations = 30
+						return c
+					}(),
+				},
+				{
+					UserID:   1,
+					ImgPath:  "inputs/AQADBBBBBBBBBBBBBBBB.jpg",
+					ResultID: "1_1621779000",
+					Config: func() primitive.Config {
+						c := primitive.New(workers)
+						c.Iterations = 40
+						return c
+					}(),
+				},
+			},
+		},
 	}
 
 	logPath := "test_log.txt"
@@ -156,3 +281,133 @@ INFO	2021/05/23 16:54:33 Sent: user id 295434263 | output outputs/295434263_1621
 		})
 	}
 }
+
+func TestRemainingTime(t *testing.T) {
+	tests := []struct {
+		limit, elapsed, expected time.Duration
+	}{
+		{0, time.Minute, 0},
+		{5 * time.Minute, 2 * time.Minute, 3 * time.Minute},
+		{5 * time.Minute, 5 * time.Minute, time.Nanosecond},
+		{5 * time.Minute, 7 * time.Minute, time.Nanosecond},
+	}
+
+	for _, tt := range tests {
+		if res := remainingTime(tt.limit, tt.elapsed); res != tt.expected {
+			t.Errorf("remainingTime(%s, %s) = %s; want %s", tt.limit, tt.elapsed, res, tt.expected)
+		}
+	}
+}
+
+func TestMatchLang(t *testing.T) {
+	app := application{
+		lang: "en",
+		translations: &translations{
+			languages: []language.Tag{language.English, language.Russian},
+		},
+	}
+	tests := map[string]string{
+		"ru":    "ru",
+		"ru-RU": "ru",
+		"en-GB": "en",
+		"de":    "en",
+		"":      "en",
+	}
+
+	for code, expected := range tests {
+		if res := app.matchLang(code); res != expected {
+			t.Errorf("matchLang(%q) = %s; want %s", code, res, expected)
+		}
+	}
+}
+
+func TestBuiltinLocales(t *testing.T) {
+	fsys, err := fs.Sub(builtinLocales, "locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := locales.Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tag, ids := range c.Missing {
+		t.Errorf("%s: messages aren't translated: %q", tag, ids)
+	}
+}
+
+func TestInputPrompt(t *testing.T) {
+	fsys, err := fs.Sub(builtinLocales, "locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := application{lang: "en", locales: fsys, errorLog: log.New(io.Discard, "", 0)}
+	if err := app.loadTranslations(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]sessions.Dialog{
+		"int":   {Kind: sessions.IntInput, Field: "iter", Min: 1, Max: 10},
+		"float": {Kind: sessions.FloatInput, Min: 0, Max: 1},
+		"color": {Kind: sessions.ColorInput},
+		"text":  {Kind: sessions.TextInput},
+		"enum":  {Kind: sessions.EnumInput, Options: []string{"jpg", "png"}},
+	}
+
+	seen := make(map[string]string)
+	for name, d := range tests {
+		prompt, retry := app.inputPrompt("ru", d, false), app.inputPrompt("ru", d, true)
+		if retry == prompt {
+			t.Errorf("%s: the prompt doesn't say that the value is incorrect: %q", name, retry)
+		}
+		if retry == app.inputPrompt("en", d, true) {
+			t.Errorf("%s: the prompt isn't translated: %q", name, retry)
+		}
+		if other, ok := seen[retry]; ok {
+			t.Errorf("%s and %s have the same prompt: %q", name, other, retry)
+		}
+		seen[retry] = name
+	}
+}
+
+func TestAuthorized(t *testing.T) {
+	store, err := users.NewStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Allow(2); err != nil {
+		t.Fatal(err)
+	}
+	// the banned user isn't served even if they are allowed
+	for _, id := range []int64{3, 4} {
+		if err := store.Ban(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Allow(4); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		access   string
+		userID   int64
+		expected bool
+	}{
+		{openAccess, 1, true},
+		{openAccess, 2, true},
+		{openAccess, 3, false},
+		{openAccess, 4, false},
+		{privateAccess, 1, false},
+		{privateAccess, 2, true},
+		{privateAccess, 4, false},
+		// the admins are always served
+		{privateAccess, 10, true},
+	}
+
+	for _, tt := range tests {
+		app := application{access: tt.access, admins: map[int64]bool{10: true}, users: store}
+		if res := app.authorized(tt.userID); res != tt.expected {
+			t.Errorf("%s: authorized(%d) = %t; want %t", tt.access, tt.userID, res, tt.expected)
+		}
+	}
+}
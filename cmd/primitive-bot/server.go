@@ -1,21 +1,32 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/lazy-void/primitive-bot/pkg/menu"
+	"github.com/lazy-void/primitive-bot/pkg/params"
+	"github.com/lazy-void/primitive-bot/pkg/queue"
+	"github.com/lazy-void/primitive-bot/pkg/results"
 	"github.com/lazy-void/primitive-bot/pkg/sessions"
 	"github.com/lazy-void/primitive-bot/pkg/tg"
 )
 
 const (
-	enqueuedLogMessage = "Enqueued: user id %d | input %s | iterations=%d, shape=%d, alpha=%d, repeat=%d, resolution=%d, extension=%s"
-	creatingLogMessage = "Creating: user id %d | input %s | output %s | iterations=%d, shape=%d, alpha=%d, repeat=%d, resolution=%d, extension=%s"
-	finishedLogMessage = "Finished: user id %d | input %s | output %s | %.1f seconds"
-	sentLogMessage     = "Sent: user id %d | output %s"
+	enqueuedLogMessage       = "Enqueued: user id %d | input %s | iterations=%d, shape=%v, alpha=%d, repeat=%d, resolution=%d, extension=%s"
+	creatingLogMessage       = "Creating: user id %d | input %s | output %s | iterations=%d, shape=%v, alpha=%d, repeat=%d, resolution=%d, extension=%s"
+	stopConditionsLogMessage = " | stop: time=%s score=%g improvement=%g"
+	scheduleLogMessage       = " | schedule: %s"
+	continueLogMessage       = " | continue: %s"
+	finishedLogMessage       = "Finished: user id %d | input %s | output %s | %.1f seconds"
+	sentLogMessage           = "Sent: user id %d | output %s"
+	skippedLogMessage        = "Skipped: user id %d | result %s is no longer available"
+	resumedLogMessage        = "Resumed: user id %d | input %s | from step %d"
+	renderedLogMessage       = "Rendered: user id %d | result %s | output %s | %.1f seconds"
+	droppedLogMessage        = "Dropped: user id %d | too many unprocessed updates"
 )
 
 func (app *application) listenAndServe() {
@@ -34,17 +45,50 @@ func (app *application) listenAndServe() {
 			continue
 		}
 
+		// updates of one user are processed in order,
+		// so they don't overwrite each other's changes
 		for _, u := range updates {
 			if u.Message.MessageID > 0 {
+				m := u.Message
 				app.infoLog.Printf("Message: text '%s' from the user '%s' with the ID '%d'",
-					u.Message.Text, u.Message.From.FirstName, u.Message.From.ID)
-				go app.processMessage(u.Message)
+					m.Text, m.From.FirstName, m.From.ID)
+				if !app.mailboxes.Post(m.From.ID, func() {
+					if !app.authorized(m.From.ID) {
+						app.infoLog.Printf(deniedLogMessage, m.From.ID)
+						app.denyMessage(m)
+						return
+					}
+					app.detectLang(m.From)
+					app.rememberUser(m.From.ID)
+					app.processMessage(m)
+				}) {
+					app.infoLog.Printf(droppedLogMessage, m.From.ID)
+				}
 				continue
 			}
 
+			q := u.CallbackQuery
 			app.infoLog.Printf("Callback Query: data '%s' from the user '%s' with the ID '%d'",
-				u.CallbackQuery.Data, u.CallbackQuery.From.FirstName, u.CallbackQuery.From.ID)
-			go app.processCallbackQuery(u.CallbackQuery)
+				q.Data, q.From.FirstName, q.From.ID)
+			if !app.mailboxes.Post(q.From.ID, func() {
+				if !app.authorized(q.From.ID) {
+					app.infoLog.Printf(deniedLogMessage, q.From.ID)
+					app.denyCallbackQuery(q)
+					return
+				}
+				app.detectLang(q.From)
+				app.rememberUser(q.From.ID)
+				app.processCallbackQuery(q)
+			}) {
+				app.infoLog.Printf(droppedLogMessage, q.From.ID)
+				// the dropped query is answered anyway, so the
+				// client of the user stops showing the progress
+				go func() {
+					if err := app.bot.AnswerCallbackQuery(q.ID, ""); err != nil {
+						app.errorLog.Printf("Error answering callback query: %s", err)
+					}
+				}()
+			}
 		}
 
 		offset = updates[numUpdates-1].UpdateID + 1
@@ -62,22 +106,54 @@ func (app *application) worker() {
 			time.Sleep(1 * time.Second)
 			continue
 		}
+		lang := app.userLang(op.UserID)
+
+		// the time limit can't exceed the one set by the operator
+		if app.maxTime > 0 && (op.Config.TimeLimit == 0 || op.Config.TimeLimit > app.maxTime) {
+			op.Config.TimeLimit = app.maxTime
+		}
 
 		// create primitive
 		start := time.Now()
-		outputPath := fmt.Sprintf("%s/%d_%d.%s", app.outDir, op.UserID, start.Unix(), op.Config.Extension)
-		app.infoLog.Printf(creatingLogMessage, op.UserID, op.ImgPath, outputPath, op.Config.Iterations, op.Config.Shape,
-			op.Config.Alpha, op.Config.Repeat, op.Config.OutputSize, op.Config.Extension)
+		id := results.NewID(op.UserID, start)
+		outputPath := fmt.Sprintf("%s/%s.%s", app.outDir, id, op.Config.FileExtension())
+		app.infoLog.Printf(creatingLogMessage+stopConditionsLogMessage, op.UserID, op.ImgPath, outputPath,
+			op.Config.Iterations, op.Config.Shapes, op.Config.Alpha, op.Config.Repeat, op.Config.OutputSize,
+			op.Config.Extension, op.Config.TimeLimit, op.Config.TargetScore, op.Config.MinImprovement)
 
-		err := op.Config.Create(op.ImgPath, outputPath)
+		scene, err := app.runOperation(op, outputPath)
+		if errors.Is(err, results.ErrNotFound) {
+			app.infoLog.Printf(skippedLogMessage, op.UserID, op.ResultID)
+			app.sendMessage(op.UserID, app.printer(lang).Sprintf("This result is no longer available."))
+			app.deleteCheckpoint()
+			app.queue.Dequeue()
+			continue
+		}
 		if err != nil {
+			// the checkpoint would make the operation fail again after restart
+			app.deleteCheckpoint()
 			app.serverError(op.UserID, err)
 			return
 		}
 		app.infoLog.Printf(finishedLogMessage, op.UserID, op.ImgPath, outputPath, time.Since(start).Seconds())
+		app.deleteCheckpoint()
+
+		// keep the shapes, so the result can be rendered again
+		err = app.results.Save(results.Result{
+			ID:      id,
+			UserID:  op.UserID,
+			ImgPath: op.ImgPath,
+			Config:  op.Config,
+			Scene:   scene,
+			Created: start,
+		})
+		if err != nil {
+			app.errorLog.Printf("Error saving result: %s", err)
+		}
 
 		// send output to the user
-		err = app.bot.SendDocument(op.UserID, outputPath)
+		err = app.bot.SendDocument(op.UserID, outputPath,
+			app.printer(lang).Sprintf("Steps: %d", scene.Steps), app.menuBuilder(lang).NewResultKeyboard(id))
 		if err != nil {
 			app.serverError(op.UserID, err)
 			return
@@ -90,39 +166,45 @@ func (app *application) worker() {
 }
 
 func (app *application) processMessage(m tg.Message) {
+	lang := app.userLang(m.From.ID)
 	switch {
 	case m.Photo != nil:
 		app.processPhoto(m)
 		return
 	case m.Document.FileID != "":
 		app.sendMessage(m.Chat.ID,
-			app.printer.Sprintf("Please send me the picture as a 'Photo', not as a 'File'."))
+			app.printer(lang).Sprintf("Please send me the picture as a 'Photo', not as a 'File'."))
 		return
 	case strings.HasPrefix(m.Text, "/"):
+		// the admin commands are checked before the others
+		command := strings.Fields(m.Text)[0]
+		if !app.authorizeCommand(m, command) {
+			return
+		}
+		if adminCommands[command] {
+			app.processAdminCommand(m, command)
+			return
+		}
 		app.processCommand(m)
 		return
 	}
 
 	// Handle user input if they are inside the input form
-	s, ok := app.sessions.Get(m.From.ID)
-	if ok && s.State == sessions.InInputDialog {
-		s.Input <- m
+	s, ok := app.sessions.InputDialog(m.From.ID)
+	if ok {
+		app.handleInput(s, m)
 		return
 	}
 
 	// Send help message
-	app.sendMessage(m.Chat.ID, app.printer.Sprintf("Send me some image."))
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Send me some image."))
 }
 
 func (app *application) processPhoto(m tg.Message) {
-	// If we already have session - delete it's menu
-	s, ok := app.sessions.Get(m.From.ID)
-	if ok {
-		err := app.bot.DeleteMessage(s.UserID, s.MenuMessageID)
-		if err != nil {
-			app.serverError(m.Chat.ID, err)
-			return
-		}
+	// parameters in the caption skip the menu
+	if strings.TrimSpace(m.Caption) != "" {
+		app.processPhotoWithCaption(m)
+		return
 	}
 
 	path, err := app.downloadPhoto(m.Photo)
@@ -131,13 +213,55 @@ func (app *application) processPhoto(m tg.Message) {
 		return
 	}
 
+	// the new session starts with the settings the user used last time
+	c, err := app.prefs.Config(m.From.ID)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return
+	}
+
 	// Create session
-	msg, err := app.bot.SendMessage(m.Chat.ID, menu.RootViewTmpl.Text, menu.RootViewTmpl.Keyboard)
+	c = app.clampConfig(c)
+	b := app.menuBuilder(app.userLang(m.From.ID))
+	root := b.NewRootView(c)
+	msg, err := app.bot.SendMessage(m.Chat.ID, root.Text, root.Keyboard)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return
+	}
+	app.addSession(sessions.NewSession(m.From.ID, msg.MessageID, path, b, c))
+}
+
+func (app *application) processPhotoWithCaption(m tg.Message) {
+	lang := app.userLang(m.From.ID)
+	c, err := app.prefs.Config(m.From.ID)
+	if err != nil {
+		app.serverError(m.Chat.ID, err)
+		return
+	}
+	c, err = app.parseParams(m.Caption, c)
+	var e *params.Error
+	if errors.As(err, &e) {
+		app.sendMessage(m.Chat.ID, app.paramsErrorMessage(lang, e))
+		return
+	}
+
+	path, err := app.downloadPhoto(m.Photo)
 	if err != nil {
 		app.serverError(m.Chat.ID, err)
 		return
 	}
-	app.sessions.Set(m.From.ID, sessions.NewSession(m.From.ID, msg.MessageID, path, app.workers), true)
+
+	pos, ok := app.enqueue(queue.Operation{
+		UserID:  m.From.ID,
+		ImgPath: path,
+		Config:  c,
+	})
+	if !ok {
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("You can't add more operations to the queue."))
+		return
+	}
+	app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Added to the queue. Position: %d.", pos))
 }
 
 func (app *application) downloadPhoto(photos []tg.PhotoSize) (string, error) {
@@ -170,23 +294,46 @@ func (app *application) downloadPhoto(photos []tg.PhotoSize) (string, error) {
 }
 
 func (app *application) processCommand(m tg.Message) {
-	switch m.Text {
+	lang := app.userLang(m.From.ID)
+	// the command can be followed by the argument, which is taken
+	// whole, so the names with spaces are rejected instead of cut
+	command := strings.Fields(m.Text)[0]
+	arg := strings.TrimSpace(strings.TrimPrefix(m.Text, command))
+
+	switch command {
 	case "/start":
-		app.sendMessage(m.Chat.ID, app.printer.Sprintf("start message"))
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("start message"))
 	case "/help":
-		app.sendMessage(m.Chat.ID, app.printer.Sprintf("help message %d", app.operationsLimit))
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("help message %d", app.operationsLimitOf(m.From.ID)))
+		if app.admins[m.From.ID] {
+			app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("admin help message"))
+		}
 	case "/status":
 		operations := app.queue.GetOperations(m.From.ID)
 		if len(operations) == 0 {
-			app.sendMessage(m.Chat.ID, app.printer.Sprintf("There aren't any operations in the queue."))
+			app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("There aren't any operations in the queue."))
 			return
 		}
 
 		for pos, op := range operations {
-			app.sendMessage(m.Chat.ID, app.createStatusMessage(op.Config, pos))
+			app.sendMessage(m.Chat.ID, app.createStatusMessage(lang, op.Config, pos))
 		}
+	case "/config":
+		app.handleConfigCommand(m, arg)
+	case "/save":
+		app.handleSaveCommand(m, arg)
+	case "/presets":
+		app.handlePresetsCommand(m)
+	case "/language":
+		app.handleLanguageCommand(m)
+	case "/preset":
+		if arg == "" {
+			app.handlePresetsCommand(m)
+			return
+		}
+		app.handlePresetCommand(m, arg)
 	default:
-		app.sendMessage(m.Chat.ID, app.printer.Sprintf("Unrecognized command."))
+		app.sendMessage(m.Chat.ID, app.printer(lang).Sprintf("Unrecognized command."))
 	}
 }
 
@@ -198,8 +345,39 @@ func (app *application) processCallbackQuery(q tg.CallbackQuery) {
 		}
 	}()
 
-	s, ok := app.sessions.Get(q.From.ID)
-	if !ok || q.Message.MessageID != s.MenuMessageID {
+	// buttons attached to the resulting images don't need a session
+	var id string
+	var num int
+	var slug string
+	switch {
+	case match(q.Data, menu.ResultViewCallback, &id):
+		app.showResultKeyboard(q, id)
+		return
+	case match(q.Data, menu.ResultSizeViewCallback, &id):
+		app.showResultSizeKeyboard(q, id)
+		return
+	case match(q.Data, menu.ResultSizeButtonCallback, &id, &num):
+		app.handleResultSizeButton(q, id, num)
+		return
+	case match(q.Data, menu.ResultExtViewCallback, &id):
+		app.showResultExtKeyboard(q, id)
+		return
+	case match(q.Data, menu.ResultExtButtonCallback, &id, &slug):
+		app.handleResultExtButton(q, id, slug)
+		return
+	case match(q.Data, menu.ResultMoreButtonCallback, &id, &num):
+		app.handleResultMoreButton(q, id, num)
+		return
+	case match(q.Data, menu.ReopenButtonCallback, &id):
+		app.handleReopenButton(q, id)
+		return
+	case match(q.Data, menu.LanguageButtonCallback, &slug):
+		app.handleLanguageButton(q, slug)
+		return
+	}
+
+	s, ok := app.sessions.Get(q.From.ID, q.Message.MessageID)
+	if !ok {
 		err := app.bot.DeleteMessage(q.Message.Chat.ID, q.Message.MessageID)
 		if err != nil {
 			app.errorLog.Printf("Error deleting message: %s", err)
@@ -207,42 +385,62 @@ func (app *application) processCallbackQuery(q tg.CallbackQuery) {
 		return
 	}
 
-	var num int
-	var slug string
 	switch {
 	case match(q.Data, menu.RootViewCallback):
 		app.showRootMenuView(s)
 	case match(q.Data, menu.CreateButtonCallback):
 		app.handleCreateButton(s, q.ID)
+	case match(q.Data, menu.UndoCallback):
+		app.handleUndoButton(s, q.ID)
+	case match(q.Data, menu.RedoCallback):
+		app.handleRedoButton(s, q.ID)
 	case match(q.Data, menu.ShapesViewCallback):
 		app.showShapesMenuView(s)
 	case match(q.Data, menu.ShapesButtonCallback, &num):
 		app.handleShapesButton(s, num)
-	case match(q.Data, menu.IterViewCallback):
-		app.showIterMenuView(s)
-	case match(q.Data, menu.IterButtonCallback, &num):
-		app.handleIterButton(s, num)
-	case match(q.Data, menu.IterInputCallback):
-		app.handleIterInput(s)
-	case match(q.Data, menu.RepViewCallback):
-		app.showRepMenuView(s)
-	case match(q.Data, menu.RepButtonCallback, &num):
-		app.handleRepButton(s, num)
-	case match(q.Data, menu.AlphaViewCallback):
-		app.showAlphaMenuView(s)
-	case match(q.Data, menu.AlphaButtonCallback, &num):
-		app.handleAlphaButton(s, num)
-	case match(q.Data, menu.AlphaInputCallback):
-		app.handleAlphaInput(s)
-	case match(q.Data, menu.ExtViewCallback):
-		app.showExtMenuView(s)
-	case match(q.Data, menu.ExtButtonCallback, &slug):
-		app.handleExtButton(s, slug)
-	case match(q.Data, menu.SizeViewCallback):
-		app.showSizeMenuView(s)
-	case match(q.Data, menu.SizeButtonCallback, &num):
-		app.handleSizeButton(s, num)
-	case match(q.Data, menu.SizeInputCallback):
-		app.handleSizeInput(s)
+	case match(q.Data, menu.InputCancelCallback):
+		app.handleInputCancel(s)
+	case match(q.Data, menu.StopViewCallback):
+		app.showStopMenuView(s)
+	case match(q.Data, menu.ScheduleViewCallback):
+		app.showScheduleMenuView(s)
+	case match(q.Data, menu.ScheduleOffCallback):
+		app.handleScheduleOff(s)
+	case match(q.Data, menu.ScheduleInputCallback):
+		app.handleScheduleInput(s)
+	case match(q.Data, menu.StylesViewCallback):
+		app.showStylesMenuView(s)
+	case match(q.Data, menu.StylesButtonCallback, &slug):
+		app.handleStyleButton(s, slug)
+	case match(q.Data, menu.DefaultsPinCallback):
+		app.handleDefaultsPin(s, q.ID)
+	case match(q.Data, menu.DefaultsResetCallback):
+		app.handleDefaultsReset(s, q.ID)
+	case match(q.Data, menu.PresetsViewCallback):
+		app.showPresetsMenuView(s)
+	case match(q.Data, menu.PresetsDeleteCallback, &slug):
+		app.handlePresetDelete(s, slug)
+	case match(q.Data, menu.PresetsButtonCallback, &slug):
+		app.handlePresetButton(s, slug)
+	default:
+		app.processOptionCallback(s, q.Data)
+	}
+}
+
+// processOptionCallback handles the callbacks of the options from the spec.
+func (app *application) processOptionCallback(s sessions.Session, data string) {
+	var value string
+	for _, o := range app.menuBuilder(s.Lang).Options {
+		switch {
+		case match(data, o.ViewCallback()):
+			app.showOptionMenuView(s, o)
+		case match(data, o.ButtonCallback(), &value):
+			app.handleOptionButton(s, o, value)
+		case o.Input && match(data, o.InputCallback()):
+			app.handleOptionInput(s, o)
+		default:
+			continue
+		}
+		return
 	}
 }
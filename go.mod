@@ -3,10 +3,11 @@ module github.com/lazy-void/primitive-bot
 go 1.16
 
 require (
-	github.com/fogleman/gg v1.3.0 // indirect
+	github.com/fogleman/gg v1.3.0
 	github.com/fogleman/primitive v0.0.0-20200504002142-0373c216458b
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
 	golang.org/x/image v0.5.0 // indirect
 	golang.org/x/text v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
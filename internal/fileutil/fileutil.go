@@ -0,0 +1,17 @@
+// Package fileutil implements helpers for the files
+// in which the stores of the bot keep their data.
+package fileutil
+
+import "os"
+
+// WriteFile replaces the content of the file at path with the data.
+// The data is written to the temporary file first and then renamed,
+// so the previous content isn't lost if the bot stops in the middle
+// of writing. The file is created with the permissions 0600.
+func WriteFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,122 @@
+// Package config sets the flags that aren't specified on the command
+// line from the environment variables and the configuration file, so
+// the secrets don't have to be passed in the arguments of the process.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Apply sets the flags of the set that aren't specified on the command
+// line. The value is taken from the environment variable named after
+// the flag with the prefix, e.g. PRIMITIVE_BOT_TOKEN_FILE for the flag
+// "token-file" and the prefix "PRIMITIVE_BOT_", and then from the YAML
+// file at path, where the keys are the names of the flags:
+//
+//	steps: 3000
+//	keep: 48h
+//	admins: [295434263]
+//
+// If path is empty, only the environment variables are used. The
+// flags that are in the list skip are taken only from the command line.
+func Apply(set *flag.FlagSet, prefix, path string, skip ...string) error {
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+	specified := make(map[string]bool)
+	set.Visit(func(f *flag.Flag) {
+		specified[f.Name] = true
+	})
+
+	values, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if set.Lookup(name) == nil || skipped[name] {
+			return fmt.Errorf("%s: unknown setting %q", path, name)
+		}
+	}
+
+	var errs []string
+	set.VisitAll(func(f *flag.Flag) {
+		if specified[f.Name] || skipped[f.Name] {
+			return
+		}
+
+		source := EnvName(prefix, f.Name)
+		value, ok := os.LookupEnv(source)
+		if !ok {
+			source = fmt.Sprintf("%s: %s", path, f.Name)
+			value, ok = values[f.Name]
+		}
+		if !ok {
+			return
+		}
+
+		if err := set.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", source, err))
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid settings: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// EnvName returns the name of the environment
+// variable that sets the flag with the name.
+func EnvName(prefix, name string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// readFile returns the values of the flags from the YAML file.
+// The lists are joined with commas.
+func readFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, v := range raw {
+		switch v := v.(type) {
+		case nil:
+			values[name] = ""
+		case []interface{}:
+			list := make([]string, len(v))
+			for i, item := range v {
+				list[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(list, ",")
+		case map[string]interface{}:
+			return nil, fmt.Errorf("%s: %s: nested settings aren't supported", path, name)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newFlagSet(args ...string) (*flag.FlagSet, *int, *time.Duration, *string, error) {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	steps := set.Int("steps", 2000, "")
+	keep := set.Duration("keep", time.Hour, "")
+	admins := set.String("admins", "", "")
+	set.String("config", "", "")
+	err := set.Parse(args)
+	return set, steps, keep, admins, err
+}
+
+func writeFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Unsetenv(key)
+	})
+}
+
+func TestApply(t *testing.T) {
+	path := writeFile(t, "steps: 3000\nkeep: 48h\nadmins: [1, 2]\n")
+	setenv(t, "TEST_KEEP", "5m")
+
+	set, steps, keep, admins, err := newFlagSet("-steps=100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(set, "TEST_", path, "config"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the command line goes first, then the environment and the file
+	if *steps != 100 {
+		t.Errorf("steps = %d; want %d", *steps, 100)
+	}
+	if *keep != 5*time.Minute {
+		t.Errorf("keep = %s; want %s", *keep, 5*time.Minute)
+	}
+	if *admins != "1,2" {
+		t.Errorf("admins = %q; want %q", *admins, "1,2")
+	}
+}
+
+func TestApplyWithoutFile(t *testing.T) {
+	setenv(t, "TEST_STEPS", "500")
+
+	set, steps, keep, _, err := newFlagSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(set, "TEST_", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if *steps != 500 {
+		t.Errorf("steps = %d; want %d", *steps, 500)
+	}
+	if *keep != time.Hour {
+		t.Errorf("keep = %s; want default %s", *keep, time.Hour)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		env  string
+	}{
+		{"Unknown setting", "color: red\n", ""},
+		{"Skipped setting", "config: other.yaml\n", ""},
+		{"Invalid value in the file", "steps: many\n", ""},
+		{"Invalid value in the environment", "", "many"},
+		{"Nested settings", "keep:\n  results: 1h\n", ""},
+		{"Malformed file", "steps: [1\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.data)
+			if tt.env != "" {
+				setenv(t, "TEST_STEPS", tt.env)
+			}
+
+			set, _, _, _, err := newFlagSet()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Apply(set, "TEST_", path, "config"); err == nil {
+				t.Error("got nil error; want error")
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if res := EnvName("PRIMITIVE_BOT_", "token-file"); res != "PRIMITIVE_BOT_TOKEN_FILE" {
+		t.Errorf("EnvName() = %s; want PRIMITIVE_BOT_TOKEN_FILE", res)
+	}
+}
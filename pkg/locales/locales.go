@@ -0,0 +1,201 @@
+// Package locales loads translations of the bot from the catalogs in the
+// gotext JSON format, so the languages can be added without rebuilding.
+package locales
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Source is the language of the messages in the code. The catalogs of
+// the other languages are validated against the catalog of this language.
+var Source = language.English
+
+// fileName is the name of the catalog in the directory of its language.
+const fileName = "messages.gotext.json"
+
+// ErrNoSource is returned when there isn't a catalog of the source language.
+var ErrNoSource = errors.New("catalog of the source language not found")
+
+// indexRe matches the argument index of the placeholder, e.g. [1] in %[1]d.
+var indexRe = regexp.MustCompile(`\[[0-9]+\]`)
+
+// file is the catalog in the gotext JSON format.
+type file struct {
+	Language string  `json:"language"`
+	Messages []entry `json:"messages"`
+}
+
+// entry is the message of the catalog.
+type entry struct {
+	ID string `json:"id"`
+	// Key is the format string that is passed to the printer. If it's
+	// empty, it's made from the message by replacing the placeholders.
+	Key          string          `json:"key"`
+	Message      string          `json:"message"`
+	Translation  json.RawMessage `json:"translation"`
+	Placeholders []placeholder   `json:"placeholders"`
+}
+
+// placeholder is the argument of the message.
+type placeholder struct {
+	ID     string `json:"id"`
+	String string `json:"string"`
+}
+
+// Catalogs contains the translations loaded from the catalogs.
+type Catalogs struct {
+	// Languages are the languages of the catalogs.
+	// The source language goes first.
+	Languages []language.Tag
+	// Missing contains IDs of the messages that aren't translated
+	// in the language. They are shown in the source language.
+	Missing map[language.Tag][]string
+
+	catalog *catalog.Builder
+}
+
+// Load loads the catalogs from the file system. The catalog of each
+// language is in the file <language>/messages.gotext.json. The catalog
+// of the source language is required.
+func Load(fsys fs.FS) (*Catalogs, error) {
+	paths, err := fs.Glob(fsys, path.Join("*", fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[language.Tag]file, len(paths))
+	var langs []language.Tag
+	for _, p := range paths {
+		f, err := readFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+
+		name := f.Language
+		if name == "" {
+			name = path.Dir(p)
+		}
+		tag, err := language.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		files[tag] = f
+		langs = append(langs, tag)
+	}
+
+	source, ok := files[Source]
+	if !ok {
+		return nil, ErrNoSource
+	}
+	// the source language goes first, the others are sorted
+	sort.Slice(langs, func(i, j int) bool {
+		if langs[i] == Source || langs[j] == Source {
+			return langs[i] == Source
+		}
+		return langs[i].String() < langs[j].String()
+	})
+
+	c := &Catalogs{
+		Languages: langs,
+		Missing:   make(map[language.Tag][]string),
+		catalog:   catalog.NewBuilder(catalog.Fallback(Source)),
+	}
+	for _, tag := range langs {
+		translated := make(map[string]string, len(files[tag].Messages))
+		for _, e := range files[tag].Messages {
+			t, err := e.translation()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", tag, err)
+			}
+			if t != "" {
+				translated[e.ID] = t
+			}
+		}
+
+		// the messages that aren't translated are
+		// shown in the source language instead
+		for _, e := range source.Messages {
+			t, ok := translated[e.ID]
+			if !ok {
+				c.Missing[tag] = append(c.Missing[tag], e.ID)
+				t, _ = e.translation()
+			}
+			if err := c.set(tag, e, t); err != nil {
+				return nil, fmt.Errorf("%s: %w", tag, err)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Printer returns the printer that translates the messages to the language.
+func (c *Catalogs) Printer(tag language.Tag) *message.Printer {
+	return message.NewPrinter(tag, message.Catalog(c.catalog))
+}
+
+// set adds the translation msg of the source message e to the catalog.
+func (c *Catalogs) set(tag language.Tag, e entry, msg string) error {
+	if msg == "" {
+		return fmt.Errorf("message %q isn't translated", e.ID)
+	}
+
+	// placeholders are replaced with the
+	// verbs that refer to the arguments
+	for _, p := range e.Placeholders {
+		msg = strings.ReplaceAll(msg, fmt.Sprintf("{%s}", p.ID), p.String)
+	}
+	return c.catalog.SetString(tag, e.key(), msg)
+}
+
+// key returns the format string of the message.
+func (e entry) key() string {
+	if e.Key != "" {
+		return e.Key
+	}
+
+	key := e.Message
+	for _, p := range e.Placeholders {
+		verb := indexRe.ReplaceAllString(p.String, "")
+		key = strings.ReplaceAll(key, fmt.Sprintf("{%s}", p.ID), verb)
+	}
+	return key
+}
+
+// translation returns the translation of the message. Only
+// the translations that are plain strings are supported.
+func (e entry) translation() (string, error) {
+	if len(e.Translation) == 0 {
+		return "", nil
+	}
+
+	var s string
+	if err := json.Unmarshal(e.Translation, &s); err != nil {
+		return "", fmt.Errorf("unsupported translation of the message %q", e.ID)
+	}
+	return s, nil
+}
+
+func readFile(fsys fs.FS, name string) (file, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return file{}, err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return file{}, err
+	}
+	return f, nil
+}
@@ -0,0 +1,105 @@
+package locales
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/text/language"
+)
+
+const enCatalog = `{
+    "language": "en",
+    "messages": [
+        {
+            "id": "start message",
+            "message": "start message",
+            "translation": "Hey!"
+        },
+        {
+            "id": "Added to the queue. Position: {Pos}.",
+            "message": "Added to the queue. Position: {Pos}.",
+            "translation": "Added to the queue. Position: {Pos}.",
+            "placeholders": [
+                {"id": "Pos", "string": "%[1]d", "type": "int", "argNum": 1, "expr": "pos"}
+            ]
+        }
+    ]
+}`
+
+const ruCatalog = `{
+    "language": "ru",
+    "messages": [
+        {
+            "id": "Added to the queue. Position: {Pos}.",
+            "message": "Added to the queue. Position: {Pos}.",
+            "translation": "Добавил в очередь. Позиция: {Pos}.",
+            "placeholders": [
+                {"id": "Pos", "string": "%[1]d", "type": "int", "argNum": 1, "expr": "pos"}
+            ]
+        }
+    ]
+}`
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ru/messages.gotext.json": {Data: []byte(ruCatalog)},
+		"en/messages.gotext.json": {Data: []byte(enCatalog)},
+		"en/out.gotext.json":      {Data: []byte("not a catalog")},
+	}
+
+	c, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []language.Tag{language.English, language.Russian}; !reflect.DeepEqual(c.Languages, expected) {
+		t.Errorf("Languages = %v; want %v", c.Languages, expected)
+	}
+	expected := map[language.Tag][]string{language.Russian: {"start message"}}
+	if !reflect.DeepEqual(c.Missing, expected) {
+		t.Errorf("Missing = %v; want %v", c.Missing, expected)
+	}
+
+	tests := []struct {
+		lang     language.Tag
+		key      string
+		args     []interface{}
+		expected string
+	}{
+		{language.English, "start message", nil, "Hey!"},
+		{language.English, "Added to the queue. Position: %d.", []interface{}{2}, "Added to the queue. Position: 2."},
+		{language.Russian, "Added to the queue. Position: %d.", []interface{}{2}, "Добавил в очередь. Позиция: 2."},
+		// the missing message is shown in the source language
+		{language.Russian, "start message", nil, "Hey!"},
+	}
+
+	for _, tt := range tests {
+		if res := c.Printer(tt.lang).Sprintf(tt.key, tt.args...); res != tt.expected {
+			t.Errorf("%s: Sprintf(%q) = %q; want %q", tt.lang, tt.key, res, tt.expected)
+		}
+	}
+}
+
+func TestLoadWithoutSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ru/messages.gotext.json": {Data: []byte(ruCatalog)},
+	}
+
+	if _, err := Load(fsys); !errors.Is(err, ErrNoSource) {
+		t.Errorf("got error %v; want %v", err, ErrNoSource)
+	}
+}
+
+func TestLoadUnsupportedTranslation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"en/messages.gotext.json": {Data: []byte(`{"language": "en", "messages": [
+			{"id": "a", "message": "a", "translation": {"select": {}}}
+		]}`)},
+	}
+
+	if _, err := Load(fsys); err == nil {
+		t.Error("got nil error; want error about the translation")
+	}
+}
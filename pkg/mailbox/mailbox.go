@@ -0,0 +1,81 @@
+// Package mailbox implements per-user mailboxes, so the updates
+// from one user are processed in order while the updates from
+// different users are processed in parallel.
+package mailbox
+
+import "sync"
+
+// Mailboxes holds a bounded mailbox for every user that
+// has unprocessed updates. Each mailbox is served by its own
+// goroutine which exits as soon as the mailbox is empty.
+type Mailboxes struct {
+	size  int
+	boxes map[int64]chan func()
+	mu    sync.Mutex
+}
+
+// New returns an initialized Mailboxes instance. Size is the number
+// of updates that can wait in the mailbox of one user while
+// the previous one is processed. It can't be less than 1.
+func New(size int) *Mailboxes {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Mailboxes{
+		size:  size,
+		boxes: map[int64]chan func(){},
+		mu:    sync.Mutex{},
+	}
+}
+
+// Post puts handler f to the mailbox of the user with userID.
+// Handlers of one user are called one at a time in the order they
+// were posted. If the mailbox is full then f is dropped
+// and false is returned.
+func (m *Mailboxes) Post(userID int64, f func()) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	box, ok := m.boxes[userID]
+	if !ok {
+		box = make(chan func(), m.size)
+		m.boxes[userID] = box
+		go m.serve(userID, box)
+	}
+
+	select {
+	case box <- f:
+		return true
+	default:
+		return false
+	}
+}
+
+// Len returns the number of handlers of the user with userID
+// that are waiting to be called.
+func (m *Mailboxes) Len(userID int64) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.boxes[userID])
+}
+
+func (m *Mailboxes) serve(userID int64, box chan func()) {
+	for {
+		// the mailbox is removed under the lock, so Post
+		// either sees it or starts a new goroutine
+		m.mu.Lock()
+		var f func()
+		select {
+		case f = <-box:
+		default:
+			delete(m.boxes, userID)
+			m.mu.Unlock()
+			return
+		}
+		m.mu.Unlock()
+
+		f()
+	}
+}
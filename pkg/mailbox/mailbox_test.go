@@ -0,0 +1,117 @@
+package mailbox
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMailboxes_PostKeepsOrderOfOneUser(t *testing.T) {
+	m := New(100)
+	var userID int64 = 123456789
+
+	var mu sync.Mutex
+	var got []int
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		i := i
+		wg.Add(1)
+		if !m.Post(userID, func() {
+			defer wg.Done()
+			// give the next handler a chance to run in parallel
+			time.Sleep(10 * time.Microsecond)
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+		}) {
+			t.Fatalf("handler %d was dropped.", i)
+		}
+	}
+	wg.Wait()
+
+	for i, n := range got {
+		if n != i {
+			t.Fatalf("handlers were called in order %v; want ascending", got)
+		}
+	}
+}
+
+func TestMailboxes_PostRunsUsersInParallel(t *testing.T) {
+	m := New(1)
+
+	// the first user is blocked until the second one is served
+	served := make(chan struct{})
+	done := make(chan struct{})
+	m.Post(1, func() {
+		<-served
+		close(done)
+	})
+	m.Post(2, func() {
+		close(served)
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("handlers of different users weren't called in parallel.")
+	}
+}
+
+func TestMailboxes_PostDropsExcessHandlers(t *testing.T) {
+	size := 3
+	m := New(size)
+	var userID int64 = 123456789
+
+	// block the mailbox with the first handler
+	started := make(chan struct{})
+	release := make(chan struct{})
+	m.Post(userID, func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	for i := 0; i < size; i++ {
+		if !m.Post(userID, func() {}) {
+			t.Fatalf("handler %d was dropped before the mailbox is full.", i)
+		}
+	}
+	if m.Post(userID, func() {}) {
+		t.Error("handler wasn't dropped when the mailbox is full.")
+	}
+	if n := m.Len(userID); n != size {
+		t.Errorf("Len() = %d; want %d", n, size)
+	}
+
+	// other users aren't affected
+	if !m.Post(987654321, func() {}) {
+		t.Error("handler of another user was dropped.")
+	}
+
+	close(release)
+}
+
+func TestMailboxes_ServeRemovesEmptyMailboxes(t *testing.T) {
+	m := New(1)
+	var userID int64 = 123456789
+
+	done := make(chan struct{})
+	m.Post(userID, func() { close(done) })
+	<-done
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.Lock()
+		_, ok := m.boxes[userID]
+		m.mu.Unlock()
+		if !ok {
+			// the user can post again
+			done := make(chan struct{})
+			m.Post(userID, func() { close(done) })
+			<-done
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("empty mailbox wasn't removed.")
+}
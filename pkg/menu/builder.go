@@ -0,0 +1,45 @@
+package menu
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/lazy-void/primitive-bot/pkg/styles"
+)
+
+// Builder makes the views of the menu in one language. It doesn't
+// change after it is created, so it can be shared by the sessions.
+type Builder struct {
+	Texts
+
+	// Options is the spec of the options that are shown in the menu.
+	// The buttons of the options are shown in the parent views
+	// in the same order.
+	Options []Option
+
+	// Templates for the different menu views.
+	RootViewTmpl     View
+	ShapesViewTmpl   View
+	StopViewTmpl     View
+	ScheduleViewTmpl View
+	StylesViewTmpl   View
+
+	lang language.Tag
+}
+
+// NewBuilder creates the builder of the menu in the language lang. The
+// text is translated by the printer, which must use the same language.
+// Limits are applied to the options and the styles are shown in
+// the view with the styles.
+func NewBuilder(lang language.Tag, p *message.Printer, l Limits, list []styles.Style) *Builder {
+	b := &Builder{Texts: NewTexts(p), lang: lang}
+	b.initOptions(l)
+	b.initTemplates()
+	b.initStyles(list)
+	return b
+}
+
+// Lang returns the language of the menu.
+func (b *Builder) Lang() string {
+	return b.lang.String()
+}
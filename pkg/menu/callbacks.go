@@ -2,7 +2,8 @@ package menu
 
 import "fmt"
 
-// Callbacks that are sent by menu buttons.
+// Callbacks that are sent by menu buttons. Callbacks
+// of the options are generated from their spec.
 var (
 	RootViewCallback     = "/"
 	CreateButtonCallback = "/create"
@@ -10,21 +11,55 @@ var (
 	ShapesViewCallback   = "/shape"
 	ShapesButtonCallback = fmt.Sprintf("%s/([0-8])", ShapesViewCallback)
 
-	IterViewCallback   = "/iter"
-	IterButtonCallback = fmt.Sprintf("%s/([0-9]+)", IterViewCallback)
-	IterInputCallback  = "/iter/input"
+	InputCancelCallback = "/input/cancel"
 
-	RepViewCallback   = "/rep"
-	RepButtonCallback = fmt.Sprintf("%s/([1-6])", RepViewCallback)
+	StopViewCallback = "/stop"
 
-	AlphaViewCallback   = "/alpha"
-	AlphaButtonCallback = fmt.Sprintf("%s/([0-9]+)", AlphaViewCallback)
-	AlphaInputCallback  = "/alpha/input"
+	StylesViewCallback   = "/style"
+	StylesButtonCallback = fmt.Sprintf("%s/([a-z0-9_-]+)", StylesViewCallback)
 
-	ExtViewCallback   = "/ext"
-	ExtButtonCallback = fmt.Sprintf("%s/(jpg|png|svg|gif)", ExtViewCallback)
+	PresetsViewCallback   = "/preset"
+	PresetsButtonCallback = fmt.Sprintf(`%s/([\p{L}\p{N}_-]+)`, PresetsViewCallback)
+	PresetsDeleteCallback = fmt.Sprintf("%s/delete", PresetsButtonCallback)
 
-	SizeViewCallback   = "/size"
-	SizeButtonCallback = fmt.Sprintf("%s/([0-9]+)", SizeViewCallback)
-	SizeInputCallback  = "/size/input"
+	DefaultsPinCallback   = "/defaults/pin"
+	DefaultsResetCallback = "/defaults/reset"
+
+	UndoCallback = "/undo"
+	RedoCallback = "/redo"
+
+	ScheduleViewCallback  = "/schedule"
+	ScheduleOffCallback   = "/schedule/off"
+	ScheduleInputCallback = "/schedule/input"
+)
+
+// ReopenCallback is sent by the button of the expired menu.
+// It contains file name of the input image.
+var (
+	ReopenCallback       = "/reopen"
+	ReopenButtonCallback = fmt.Sprintf(`%s/([A-Za-z0-9_-]+\.jpg)`, ReopenCallback)
+)
+
+// LanguageCallback is sent by the buttons of the view with the
+// languages, which isn't a part of the menu. It contains the BCP 47 tag
+// of the language, e.g. "en" or "pt-BR".
+var (
+	LanguageCallback       = "/language"
+	LanguageButtonCallback = fmt.Sprintf("%s/([A-Za-z]+(?:-[A-Za-z0-9]+)*)", LanguageCallback)
+)
+
+// Callbacks that are sent by buttons attached to the resulting images.
+// Each of them contains ID of the result.
+var (
+	ResultCallback = "/res"
+
+	ResultViewCallback = fmt.Sprintf("%s/([0-9]+_[0-9]+)", ResultCallback)
+
+	ResultSizeViewCallback   = fmt.Sprintf("%s/size", ResultViewCallback)
+	ResultSizeButtonCallback = fmt.Sprintf("%s/([0-9]+)", ResultSizeViewCallback)
+
+	ResultExtViewCallback   = fmt.Sprintf("%s/ext", ResultViewCallback)
+	ResultExtButtonCallback = fmt.Sprintf("%s/(jpg|png|svg|gif|apng|json)", ResultExtViewCallback)
+
+	ResultMoreButtonCallback = fmt.Sprintf("%s/more/([0-9]+)", ResultViewCallback)
 )
@@ -0,0 +1,24 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// NewExpiredView creates view that replaces the menu of the expired
+// session. If imgName isn't empty, the view has the button that opens
+// the menu again for the input image with this file name.
+func (b *Builder) NewExpiredView(imgName string) View {
+	keyboard := [][]tg.InlineKeyboardButton{}
+	if imgName != "" {
+		keyboard = append(keyboard, []tg.InlineKeyboardButton{
+			{Text: b.ReopenButton, CallbackData: fmt.Sprintf("%s/%s", ReopenCallback, imgName)},
+		})
+	}
+
+	return View{
+		Text:     b.ExpiredMenu,
+		Keyboard: tg.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	}
+}
@@ -0,0 +1,31 @@
+package menu
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+func TestNewExpiredView(t *testing.T) {
+	b := newTestBuilder()
+
+	res := b.NewExpiredView("AQADBAADr6cxG.jpg")
+	expected := [][]tg.InlineKeyboardButton{
+		{{Text: b.ReopenButton, CallbackData: "/reopen/AQADBAADr6cxG.jpg"}},
+	}
+	if res.Text != b.ExpiredMenu {
+		t.Errorf("Got menu view text: %s; want: %s", res.Text, b.ExpiredMenu)
+	}
+	if !reflect.DeepEqual(res.Keyboard.InlineKeyboard, expected) {
+		t.Errorf("Got InlineKeyboard: %+v;\n want: %+v", res.Keyboard.InlineKeyboard, expected)
+	}
+	if !matchesAny(expected[0][0].CallbackData, []string{ReopenButtonCallback}) {
+		t.Errorf("Callback %s doesn't match %s", expected[0][0].CallbackData, ReopenButtonCallback)
+	}
+
+	res = b.NewExpiredView("")
+	if len(res.Keyboard.InlineKeyboard) != 0 {
+		t.Errorf("Got InlineKeyboard: %+v; want no buttons", res.Keyboard.InlineKeyboard)
+	}
+}
@@ -0,0 +1,16 @@
+package menu
+
+import "github.com/lazy-void/primitive-bot/pkg/tg"
+
+// NewInputView creates view of the input dialog
+// with the prompt and the button that cancels it.
+func (b *Builder) NewInputView(prompt string) View {
+	return View{
+		Text: prompt,
+		Keyboard: tg.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tg.InlineKeyboardButton{
+				{{Text: b.CancelButton, CallbackData: InputCancelCallback}},
+			},
+		},
+	}
+}
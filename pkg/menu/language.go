@@ -0,0 +1,25 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// NewLanguageView creates view for selecting the language of the bot.
+// Each language is shown by its name in this language, the language
+// of the builder is selected.
+func (b *Builder) NewLanguageView(list []*Builder) View {
+	keyboard := make([][]tg.InlineKeyboardButton, len(list))
+	for i, l := range list {
+		keyboard[i] = []tg.InlineKeyboardButton{
+			{Text: l.LanguageName, CallbackData: fmt.Sprintf("%s/%s", LanguageCallback, l.Lang())},
+		}
+	}
+
+	view := View{
+		Text:     b.LanguageMenu,
+		Keyboard: tg.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	}
+	return NewMenuView(view, fmt.Sprintf("%s/%s", LanguageCallback, b.Lang()))
+}
@@ -0,0 +1,47 @@
+package menu
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+func TestNewLanguageView(t *testing.T) {
+	en := newTestBuilder()
+	ru := NewBuilder(language.Russian, message.NewPrinter(language.Russian), Limits{}, nil)
+
+	res := ru.NewLanguageView([]*Builder{en, ru})
+	expected := [][]tg.InlineKeyboardButton{
+		{{Text: en.LanguageName, CallbackData: "/language/en"}},
+		{{Text: "👉 " + ru.LanguageName, CallbackData: "/language/ru"}},
+	}
+	if res.Text != ru.LanguageMenu {
+		t.Errorf("Got menu view text: %s; want: %s", res.Text, ru.LanguageMenu)
+	}
+	if !reflect.DeepEqual(res.Keyboard.InlineKeyboard, expected) {
+		t.Errorf("Got InlineKeyboard: %+v;\n want: %+v", res.Keyboard.InlineKeyboard, expected)
+	}
+	for _, row := range expected {
+		if !matchesAny(row[0].CallbackData, []string{LanguageButtonCallback}) {
+			t.Errorf("Callback %s doesn't match %s", row[0].CallbackData, LanguageButtonCallback)
+		}
+	}
+}
+
+func TestNewLanguageView_RegionTag(t *testing.T) {
+	en := newTestBuilder()
+	pt := NewBuilder(language.BrazilianPortuguese, message.NewPrinter(language.BrazilianPortuguese), Limits{}, nil)
+
+	res := en.NewLanguageView([]*Builder{en, pt})
+	data := res.Keyboard.InlineKeyboard[1][0].CallbackData
+	if data != "/language/pt-BR" {
+		t.Errorf("Got callback %s; want /language/pt-BR", data)
+	}
+	if !matchesAny(data, []string{LanguageButtonCallback}) {
+		t.Errorf("Callback %s doesn't match %s", data, LanguageButtonCallback)
+	}
+}
@@ -2,8 +2,6 @@
 package menu
 
 import (
-	"fmt"
-
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
 	"github.com/lazy-void/primitive-bot/pkg/tg"
 )
@@ -15,33 +13,24 @@ type View struct {
 	Keyboard tg.InlineKeyboardMarkup
 }
 
-// Menu represents menu made of View instances.
+// Menu represents menu made of View instances. Views
+// of the options are made from the config when they
+// are shown, see Option.View.
 type Menu struct {
-	RootView   View
-	ShapesView View
-	IterView   View
-	RepView    View
-	AlphaView  View
-	ExtView    View
-	SizeView   View
+	RootView     View
+	ShapesView   View
+	StopView     View
+	ScheduleView View
+	StylesView   View
 }
 
 // New initializes instance of Menu.
-func New(c primitive.Config) Menu {
-	shapesCallback := fmt.Sprintf("%s/%d", ShapesViewCallback, c.Shape)
-	iterCallback := fmt.Sprintf("%s/%d", IterViewCallback, c.Iterations)
-	repCallback := fmt.Sprintf("%s/%d", RepViewCallback, c.Repeat)
-	alphaCallback := fmt.Sprintf("%s/%d", AlphaViewCallback, c.Alpha)
-	extCallback := fmt.Sprintf("%s/%s", ExtViewCallback, c.Extension)
-	sizeCallback := fmt.Sprintf("%s/%d", SizeViewCallback, c.OutputSize)
-
+func (b *Builder) New(c primitive.Config) Menu {
 	return Menu{
-		RootView:   NewMenuView(RootViewTmpl, ""),
-		ShapesView: NewMenuView(ShapesViewTmpl, shapesCallback),
-		IterView:   NewMenuView(IterViewTmpl, iterCallback),
-		RepView:    NewMenuView(RepViewTmpl, repCallback),
-		AlphaView:  NewMenuView(AlphaViewTmpl, alphaCallback),
-		ExtView:    NewMenuView(ExtViewTmpl, extCallback),
-		SizeView:   NewMenuView(SizeViewTmpl, sizeCallback),
+		RootView:     b.NewRootView(c),
+		ShapesView:   b.NewShapesView(c.Shapes),
+		StopView:     NewMenuView(b.StopViewTmpl, ""),
+		ScheduleView: b.NewScheduleView(c.Schedule),
+		StylesView:   NewMenuView(b.StylesViewTmpl, ""),
 	}
 }
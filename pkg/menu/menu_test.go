@@ -1,49 +1,48 @@
 package menu
 
 import (
-	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
+	"github.com/lazy-void/primitive-bot/pkg/styles"
 )
 
 func TestNew(t *testing.T) {
-	InitText(message.NewPrinter(language.English))
+	list := []styles.Style{{ID: "a", Name: map[string]string{"en": "A"}}}
+	b := NewBuilder(language.English, message.NewPrinter(language.English), Limits{}, list)
 	c := primitive.Config{
-		Shape:      primitive.ShapePolygon,
-		Iterations: 1000,
-		Repeat:     2,
-		Alpha:      255,
-		Extension:  "png",
-		OutputSize: 256,
+		Shapes:         primitive.NewShapeSet(primitive.ShapePolygon, primitive.ShapeCircle),
+		Iterations:     1000,
+		Repeat:         2,
+		Alpha:          255,
+		Extension:      "png",
+		OutputSize:     256,
+		TimeLimit:      5 * time.Minute,
+		TargetScore:    95,
+		MinImprovement: 0.05,
+		Schedule:       "1-100:a=255",
 	}
-	ShapesView := NewMenuView(ShapesViewTmpl, fmt.Sprintf("%s/%d", ShapesViewCallback, c.Shape))
-	IterView := NewMenuView(IterViewTmpl, fmt.Sprintf("%s/%d", IterViewCallback, c.Iterations))
-	RepView := NewMenuView(RepViewTmpl, fmt.Sprintf("%s/%d", RepViewCallback, c.Repeat))
-	AlphaView := NewMenuView(AlphaViewTmpl, fmt.Sprintf("%s/%d", AlphaViewCallback, c.Alpha))
-	ExtView := NewMenuView(ExtViewTmpl, fmt.Sprintf("%s/%s", ExtViewCallback, c.Extension))
-	SizeView := NewMenuView(SizeViewTmpl, fmt.Sprintf("%s/%d", SizeViewCallback, c.OutputSize))
+	ShapesView := b.NewShapesView(c.Shapes)
+	ScheduleView := b.NewScheduleView(c.Schedule)
+	RootView := b.NewRootView(c)
 
-	menu := New(c)
+	menu := b.New(c)
 
 	switch {
-	case !reflect.DeepEqual(menu.RootView, RootViewTmpl):
-		t.Errorf("RootView: %+v;\n want: %+v", menu.RootView, RootViewTmpl)
+	case !reflect.DeepEqual(menu.RootView, RootView):
+		t.Errorf("RootView: %+v;\n want: %+v", menu.RootView, RootView)
 	case !reflect.DeepEqual(menu.ShapesView, ShapesView):
 		t.Errorf("ShapesView: %+v;\n want: %+v", menu.ShapesView, ShapesView)
-	case !reflect.DeepEqual(menu.IterView, IterView):
-		t.Errorf("IterView: %+v;\n want: %+v", menu.IterView, IterView)
-	case !reflect.DeepEqual(menu.RepView, RepView):
-		t.Errorf("RepView: %+v;\n want: %+v", menu.RepView, RepView)
-	case !reflect.DeepEqual(menu.AlphaView, AlphaView):
-		t.Errorf("AlphaView: %+v;\n want: %+v", menu.AlphaView, AlphaView)
-	case !reflect.DeepEqual(menu.ExtView, ExtView):
-		t.Errorf("ExtView: %+v;\n want: %+v", menu.ExtView, ExtView)
-	case !reflect.DeepEqual(menu.SizeView, SizeView):
-		t.Errorf("SizeView: %+v;\n want: %+v", menu.SizeView, SizeView)
+	case !reflect.DeepEqual(menu.StopView, b.StopViewTmpl):
+		t.Errorf("StopView: %+v;\n want: %+v", menu.StopView, b.StopViewTmpl)
+	case !reflect.DeepEqual(menu.ScheduleView, ScheduleView):
+		t.Errorf("ScheduleView: %+v;\n want: %+v", menu.ScheduleView, ScheduleView)
+	case !reflect.DeepEqual(menu.StylesView, b.StylesViewTmpl):
+		t.Errorf("StylesView: %+v;\n want: %+v", menu.StylesView, b.StylesViewTmpl)
 	}
 }
@@ -0,0 +1,362 @@
+package menu
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// Choice is the button of the option view that selects the value.
+type Choice struct {
+	Value string
+	// Label is the text of the button. If it's empty, the value is shown.
+	Label string
+}
+
+// Option is the spec of the view that sets one field of the config.
+// Keyboard, callbacks, validation and the selection marker of
+// the view are generated from it.
+type Option struct {
+	// Name identifies the option. It is also used as the field
+	// of the input dialog that asks to enter the value.
+	Name string
+	// Parent is the callback of the view with the button of the option.
+	// Callbacks of the option are nested in it.
+	Parent string
+	// Button is the text of the button in the parent view.
+	Button string
+	// Text is the text of the view.
+	Text string
+	// Choices are the rows of the buttons with the values.
+	Choices [][]Choice
+	// Numeric options accept integers from Min to Max, Max equal to zero
+	// means no limit. The other options accept only the values of the choices.
+	Numeric  bool
+	Min, Max int
+	// Input adds the button that asks to enter any value in the range.
+	Input bool
+	// Get returns the value of the field in the config.
+	Get func(c primitive.Config) string
+	// Set returns the config with the field set to the valid value.
+	Set func(c primitive.Config, v string) primitive.Config
+
+	// text of the input and back buttons in the language of the builder
+	other, back string
+}
+
+// Limits are the max values of the options set by the operator.
+// Zero value means no limit.
+type Limits struct {
+	MaxIter int
+	MaxSize int
+	MaxTime time.Duration
+}
+
+// initOptions initializes the spec of the options
+// with the max values limited by l.
+func (b *Builder) initOptions(l Limits) {
+	b.Options = []Option{
+		{
+			Name:   "iter",
+			Parent: RootViewCallback,
+			Button: b.IterButton,
+			Text:   b.IterMenu,
+			Choices: [][]Choice{
+				values("100", "200", "400"),
+				values("800", "1000", "2000"),
+			},
+			Numeric: true,
+			Min:     1,
+			Max:     l.MaxIter,
+			Input:   true,
+			Get:     func(c primitive.Config) string { return strconv.Itoa(c.Iterations) },
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.Iterations = atoi(v)
+				return c
+			},
+		},
+		{
+			Name:   "rep",
+			Parent: RootViewCallback,
+			Button: b.RepButton,
+			Text:   b.RepMenu,
+			Choices: [][]Choice{
+				values("1", "2", "3"),
+				values("4", "5", "6"),
+			},
+			Numeric: true,
+			Min:     primitive.MinRepeat,
+			Max:     primitive.MaxRepeat,
+			Get:     func(c primitive.Config) string { return strconv.Itoa(c.Repeat) },
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.Repeat = atoi(v)
+				return c
+			},
+		},
+		{
+			Name:   "alpha",
+			Parent: RootViewCallback,
+			Button: b.AlphaButton,
+			Text:   b.AlphaMenu,
+			Choices: [][]Choice{
+				{{Value: "0", Label: b.AutoButton}},
+				values("32", "64", "128", "255"),
+			},
+			Numeric: true,
+			Min:     0,
+			Max:     255,
+			Input:   true,
+			Get:     func(c primitive.Config) string { return strconv.Itoa(c.Alpha) },
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.Alpha = atoi(v)
+				return c
+			},
+		},
+		{
+			Name:   "ext",
+			Parent: RootViewCallback,
+			Button: b.ExtButton,
+			Text:   b.ExtMenu,
+			Choices: [][]Choice{
+				values("jpg", "png", "svg", "json"),
+				values("gif", "apng"),
+			},
+			Get: func(c primitive.Config) string { return c.Extension },
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.Extension = v
+				return c
+			},
+		},
+		{
+			Name:   "size",
+			Parent: RootViewCallback,
+			Button: b.SizeButton,
+			Text:   b.SizeMenu,
+			Choices: [][]Choice{
+				values("256", "512", "720"),
+				values("1024", "1280", "1920"),
+			},
+			Numeric: true,
+			Min:     256,
+			Max:     l.MaxSize,
+			Input:   true,
+			Get:     func(c primitive.Config) string { return strconv.Itoa(c.OutputSize) },
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.OutputSize = atoi(v)
+				return c
+			},
+		},
+		// the time limit is specified in seconds
+		{
+			Name:   "time",
+			Parent: StopViewCallback,
+			Button: b.TimeButton,
+			Text:   b.TimeMenu,
+			Choices: [][]Choice{
+				{{Value: "0", Label: b.OffButton}},
+				{{Value: "60", Label: "1m"}, {Value: "300", Label: "5m"},
+					{Value: "900", Label: "15m"}, {Value: "1800", Label: "30m"}},
+			},
+			Numeric: true,
+			Max:     int(l.MaxTime.Seconds()),
+			Get:     func(c primitive.Config) string { return strconv.Itoa(int(c.TimeLimit.Seconds())) },
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.TimeLimit = time.Duration(atoi(v)) * time.Second
+				return c
+			},
+		},
+		{
+			Name:   "score",
+			Parent: StopViewCallback,
+			Button: b.ScoreButton,
+			Text:   b.ScoreMenu,
+			Choices: [][]Choice{
+				{{Value: "0", Label: b.OffButton}},
+				{{Value: "90", Label: "90%"}, {Value: "95", Label: "95%"},
+					{Value: "98", Label: "98%"}, {Value: "99", Label: "99%"}},
+			},
+			Numeric: true,
+			Max:     99,
+			Get:     func(c primitive.Config) string { return strconv.Itoa(int(c.TargetScore)) },
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.TargetScore = float64(atoi(v))
+				return c
+			},
+		},
+		// the improvement is specified in hundredths of a percent
+		{
+			Name:   "plateau",
+			Parent: StopViewCallback,
+			Button: b.PlateauButton,
+			Text:   b.PlateauMenu,
+			Choices: [][]Choice{
+				{{Value: "0", Label: b.OffButton}},
+				{{Value: "1", Label: "0.01%"}, {Value: "5", Label: "0.05%"}, {Value: "10", Label: "0.1%"}},
+			},
+			Numeric: true,
+			Max:     100,
+			Get: func(c primitive.Config) string {
+				return strconv.Itoa(int(math.Round(c.MinImprovement * 100)))
+			},
+			Set: func(c primitive.Config, v string) primitive.Config {
+				c.MinImprovement = float64(atoi(v)) / 100
+				return c
+			},
+		},
+	}
+
+	for i := range b.Options {
+		b.Options[i].other = b.OtherButton
+		b.Options[i].back = b.BackButton
+	}
+}
+
+// FindOption returns the option with the name. If there isn't
+// such option, second return parameter will be equal to false.
+func (b *Builder) FindOption(name string) (Option, bool) {
+	for _, o := range b.Options {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
+
+// ViewCallback returns the callback that opens the view of the option.
+func (o Option) ViewCallback() string {
+	return path.Join(o.Parent, o.Name)
+}
+
+// ButtonCallback returns the pattern of the callbacks that select the value.
+func (o Option) ButtonCallback() string {
+	if o.Numeric {
+		return fmt.Sprintf("%s/([0-9]+)", o.ViewCallback())
+	}
+
+	var list []string
+	for _, row := range o.Choices {
+		for _, ch := range row {
+			list = append(list, regexp.QuoteMeta(ch.Value))
+		}
+	}
+	return fmt.Sprintf("%s/(%s)", o.ViewCallback(), strings.Join(list, "|"))
+}
+
+// InputCallback returns the callback that opens the input dialog.
+func (o Option) InputCallback() string {
+	return fmt.Sprintf("%s/input", o.ViewCallback())
+}
+
+// Valid reports whether the value can be set to the option.
+func (o Option) Valid(v string) bool {
+	if !o.Numeric {
+		for _, row := range o.Choices {
+			for _, ch := range row {
+				if ch.Value == v {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return false
+	}
+	return n >= o.Min && (o.Max == 0 || n <= o.Max)
+}
+
+// Apply returns the config with the value set to the field of the option. If
+// the value is incorrect, second return parameter will be equal to false.
+func (o Option) Apply(c primitive.Config, v string) (primitive.Config, bool) {
+	if !o.Valid(v) {
+		return c, false
+	}
+	return o.Set(c, v), true
+}
+
+// Template returns the view of the option with nothing selected.
+// The choices above the limits set by the operator aren't shown.
+func (o Option) Template() View {
+	var keyboard [][]tg.InlineKeyboardButton
+	for _, row := range o.Choices {
+		var buttons []tg.InlineKeyboardButton
+		for _, ch := range row {
+			if !o.Valid(ch.Value) {
+				continue
+			}
+
+			text := ch.Label
+			if text == "" {
+				text = ch.Value
+			}
+			buttons = append(buttons, tg.InlineKeyboardButton{
+				Text:         text,
+				CallbackData: fmt.Sprintf("%s/%s", o.ViewCallback(), ch.Value),
+			})
+		}
+		if len(buttons) > 0 {
+			keyboard = append(keyboard, buttons)
+		}
+	}
+	if o.Input {
+		keyboard = append(keyboard, []tg.InlineKeyboardButton{
+			{Text: o.other, CallbackData: o.InputCallback()},
+		})
+	}
+	keyboard = append(keyboard, []tg.InlineKeyboardButton{
+		{Text: o.back, CallbackData: o.Parent},
+	})
+
+	return View{
+		Text:     o.Text,
+		Keyboard: tg.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	}
+}
+
+// View returns the view of the option with the value from the config
+// selected. If there isn't the button with this value, but the value
+// can be entered, the input button is selected and shows the value.
+func (o Option) View(c primitive.Config) View {
+	v := o.Get(c)
+	for _, row := range o.Choices {
+		for _, ch := range row {
+			if ch.Value == v {
+				return NewMenuView(o.Template(), fmt.Sprintf("%s/%s", o.ViewCallback(), v))
+			}
+		}
+	}
+
+	if o.Input {
+		return NewMenuView(o.Template(), o.InputCallback(), fmt.Sprintf("%s (%s)", o.other, v))
+	}
+	return o.Template()
+}
+
+// button returns the button that opens the view of the option.
+func (o Option) button() tg.InlineKeyboardButton {
+	return tg.InlineKeyboardButton{Text: o.Button, CallbackData: o.ViewCallback()}
+}
+
+// values returns the choices with the values shown on the buttons.
+func values(list ...string) []Choice {
+	choices := make([]Choice, len(list))
+	for i, v := range list {
+		choices[i] = Choice{Value: v}
+	}
+	return choices
+}
+
+// atoi converts the value that is already validated.
+func atoi(v string) int {
+	n, _ := strconv.Atoi(v)
+	return n
+}
@@ -0,0 +1,181 @@
+package menu
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+func TestOption_Callbacks(t *testing.T) {
+	b := newTestBuilder()
+
+	tests := []struct {
+		name   string
+		view   string
+		button string
+		input  string
+	}{
+		{name: "iter", view: "/iter", button: "/iter/([0-9]+)", input: "/iter/input"},
+		{name: "ext", view: "/ext", button: "/ext/(jpg|png|svg|json|gif|apng)", input: "/ext/input"},
+		{name: "time", view: "/stop/time", button: "/stop/time/([0-9]+)", input: "/stop/time/input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, ok := b.FindOption(tt.name)
+			if !ok {
+				t.Fatalf("option %s isn't found", tt.name)
+			}
+			if res := o.ViewCallback(); res != tt.view {
+				t.Errorf("ViewCallback() = %s; want %s", res, tt.view)
+			}
+			if res := o.ButtonCallback(); res != tt.button {
+				t.Errorf("ButtonCallback() = %s; want %s", res, tt.button)
+			}
+			if res := o.InputCallback(); res != tt.input {
+				t.Errorf("InputCallback() = %s; want %s", res, tt.input)
+			}
+		})
+	}
+}
+
+func TestOption_TemplateCallbacks(t *testing.T) {
+	b := newTestBuilder()
+
+	for _, o := range b.Options {
+		t.Run(o.Name, func(t *testing.T) {
+			callbacks := []string{o.ButtonCallback(), o.InputCallback(), o.Parent}
+			for _, row := range o.Template().Keyboard.InlineKeyboard {
+				for _, button := range row {
+					if !matchesAny(button.CallbackData, callbacks) {
+						t.Errorf("Callback %s doesn't match any of %v", button.CallbackData, callbacks)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestOption_TemplateHidesChoicesAboveLimits(t *testing.T) {
+	p := message.NewPrinter(language.English)
+	b := NewBuilder(language.English, p, Limits{MaxIter: 1000, MaxTime: 15 * time.Minute}, nil)
+
+	tests := map[string][]string{
+		"iter": {"/iter/2000"},
+		"time": {"/stop/time/1800"},
+	}
+
+	for name, hidden := range tests {
+		o, ok := b.FindOption(name)
+		if !ok {
+			t.Fatalf("option %s isn't found", name)
+		}
+		for _, row := range o.Template().Keyboard.InlineKeyboard {
+			for _, button := range row {
+				for _, data := range hidden {
+					if button.CallbackData == data {
+						t.Errorf("%s: the button %s is shown", name, data)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestOption_Apply(t *testing.T) {
+	l := Limits{MaxIter: 2000, MaxSize: 3840, MaxTime: 10 * time.Minute}
+	b := NewBuilder(language.English, message.NewPrinter(language.English), l, nil)
+
+	tests := []struct {
+		option string
+		value  string
+		ok     bool
+		check  func(c primitive.Config) bool
+	}{
+		{"iter", "500", true, func(c primitive.Config) bool { return c.Iterations == 500 }},
+		{"iter", "0", false, nil},
+		{"iter", "2001", false, nil},
+		{"alpha", "0", true, func(c primitive.Config) bool { return c.Alpha == 0 }},
+		{"alpha", "256", false, nil},
+		{"ext", "png", true, func(c primitive.Config) bool { return c.Extension == "png" }},
+		{"ext", "bmp", false, nil},
+		{"size", "255", false, nil},
+		{"time", "300", true, func(c primitive.Config) bool { return c.TimeLimit == 5*time.Minute }},
+		{"time", "900", false, nil},
+		{"score", "100", false, nil},
+		{"plateau", "5", true, func(c primitive.Config) bool { return c.MinImprovement == 0.05 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.option+"="+tt.value, func(t *testing.T) {
+			o, _ := b.FindOption(tt.option)
+			c := primitive.New(1)
+			res, ok := o.Apply(c, tt.value)
+			if ok != tt.ok {
+				t.Fatalf("Apply() ok = %t; want %t", ok, tt.ok)
+			}
+			if !ok && res != c {
+				t.Errorf("config is changed by the incorrect value: %+v", res)
+			}
+			if ok && !tt.check(res) {
+				t.Errorf("config isn't changed correctly: %+v", res)
+			}
+		})
+	}
+}
+
+func TestOption_View(t *testing.T) {
+	b := newTestBuilder()
+	o, _ := b.FindOption("iter")
+	c := primitive.New(1)
+
+	// the value has the button
+	c.Iterations = 400
+	if res, expected := o.View(c), NewMenuView(o.Template(), "/iter/400"); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Got menu view: %+v;\n want: %+v", res, expected)
+	}
+
+	// the value was entered
+	c.Iterations = 500
+	expected := NewMenuView(o.Template(), "/iter/input", "Other (500)")
+	if res := o.View(c); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Got menu view: %+v;\n want: %+v", res, expected)
+	}
+
+	// the value can't be entered
+	o, _ = b.FindOption("rep")
+	c.Repeat = 7
+	if res := o.View(c); !reflect.DeepEqual(res, o.Template()) {
+		t.Errorf("Got menu view: %+v;\n want: %+v", res, o.Template())
+	}
+}
+
+func TestBuilderPlacesOptions(t *testing.T) {
+	b := newTestBuilder()
+
+	expected := [][]tg.InlineKeyboardButton{
+		{{Text: b.ShapesButton, CallbackData: ShapesViewCallback}, {Text: b.IterButton, CallbackData: "/iter"}},
+		{{Text: b.RepButton, CallbackData: "/rep"}, {Text: b.AlphaButton, CallbackData: "/alpha"}},
+		{{Text: b.ExtButton, CallbackData: "/ext"}, {Text: b.SizeButton, CallbackData: "/size"}},
+		{{Text: b.StopButton, CallbackData: StopViewCallback}, {Text: b.ScheduleButton, CallbackData: ScheduleViewCallback}},
+	}
+	if res := b.RootViewTmpl.Keyboard.InlineKeyboard[2:6]; !reflect.DeepEqual(res, expected) {
+		t.Errorf("Got root keyboard rows: %+v;\n want: %+v", res, expected)
+	}
+
+	expected = [][]tg.InlineKeyboardButton{
+		{{Text: b.TimeButton, CallbackData: "/stop/time"}},
+		{{Text: b.ScoreButton, CallbackData: "/stop/score"}},
+		{{Text: b.PlateauButton, CallbackData: "/stop/plateau"}},
+		{{Text: b.BackButton, CallbackData: RootViewCallback}},
+	}
+	if res := b.StopViewTmpl.Keyboard.InlineKeyboard; !reflect.DeepEqual(res, expected) {
+		t.Errorf("Got stop keyboard: %+v;\n want: %+v", res, expected)
+	}
+}
@@ -0,0 +1,39 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// NewPresetsView creates view with the presets of the user. Each preset
+// has a button that applies it and a button that deletes it.
+func (b *Builder) NewPresetsView(names []string) View {
+	if len(names) == 0 {
+		return View{
+			Text: b.PresetsEmptyMenu,
+			Keyboard: tg.InlineKeyboardMarkup{
+				InlineKeyboard: [][]tg.InlineKeyboardButton{
+					{{Text: b.BackButton, CallbackData: RootViewCallback}},
+				},
+			},
+		}
+	}
+
+	keyboard := make([][]tg.InlineKeyboardButton, 0, len(names)+1)
+	for _, name := range names {
+		callback := fmt.Sprintf("%s/%s", PresetsViewCallback, name)
+		keyboard = append(keyboard, []tg.InlineKeyboardButton{
+			{Text: name, CallbackData: callback},
+			{Text: "🗑", CallbackData: fmt.Sprintf("%s/delete", callback)},
+		})
+	}
+	keyboard = append(keyboard, []tg.InlineKeyboardButton{
+		{Text: b.BackButton, CallbackData: RootViewCallback},
+	})
+
+	return View{
+		Text:     b.PresetsMenu,
+		Keyboard: tg.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	}
+}
@@ -0,0 +1,75 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// ContinueSteps contains the numbers of steps by which
+// the resulting image can be continued.
+var ContinueSteps = []int{100, 200, 500}
+
+// NewResultKeyboard creates keyboard that is attached
+// to the resulting image with the given ID.
+func (b *Builder) NewResultKeyboard(id string) tg.InlineKeyboardMarkup {
+	callback := fmt.Sprintf("%s/%s", ResultCallback, id)
+
+	more := make([]tg.InlineKeyboardButton, len(ContinueSteps))
+	for i, n := range ContinueSteps {
+		more[i] = tg.InlineKeyboardButton{
+			Text:         b.MoreButtons[n],
+			CallbackData: fmt.Sprintf("%s/more/%d", callback, n),
+		}
+	}
+
+	return tg.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tg.InlineKeyboardButton{
+			{
+				{Text: b.ResizeButton, CallbackData: fmt.Sprintf("%s/size", callback)},
+				{Text: b.FormatButton, CallbackData: fmt.Sprintf("%s/ext", callback)},
+			},
+			more,
+		},
+	}
+}
+
+// NewResultSizeKeyboard creates keyboard for selecting
+// new size of the resulting image with the given ID.
+func (b *Builder) NewResultSizeKeyboard(id string) tg.InlineKeyboardMarkup {
+	callback := fmt.Sprintf("%s/%s", ResultCallback, id)
+	button := func(size int) tg.InlineKeyboardButton {
+		return tg.InlineKeyboardButton{
+			Text:         fmt.Sprint(size),
+			CallbackData: fmt.Sprintf("%s/size/%d", callback, size),
+		}
+	}
+
+	return tg.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tg.InlineKeyboardButton{
+			{button(256), button(512), button(720)},
+			{button(1024), button(1280), button(1920)},
+			{{Text: b.BackButton, CallbackData: callback}},
+		},
+	}
+}
+
+// NewResultExtKeyboard creates keyboard for selecting
+// new extension of the resulting image with the given ID.
+func (b *Builder) NewResultExtKeyboard(id string) tg.InlineKeyboardMarkup {
+	callback := fmt.Sprintf("%s/%s", ResultCallback, id)
+	button := func(ext string) tg.InlineKeyboardButton {
+		return tg.InlineKeyboardButton{
+			Text:         ext,
+			CallbackData: fmt.Sprintf("%s/ext/%s", callback, ext),
+		}
+	}
+
+	return tg.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tg.InlineKeyboardButton{
+			{button("jpg"), button("png"), button("svg"), button("json")},
+			{button("gif"), button("apng")},
+			{{Text: b.BackButton, CallbackData: callback}},
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package menu
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+func TestResultKeyboards(t *testing.T) {
+	b := newTestBuilder()
+
+	id := "123456789_1621774849"
+	callbacks := []string{
+		ResultViewCallback,
+		ResultSizeViewCallback,
+		ResultSizeButtonCallback,
+		ResultExtViewCallback,
+		ResultExtButtonCallback,
+		ResultMoreButtonCallback,
+	}
+	keyboards := map[string]tg.InlineKeyboardMarkup{
+		"NewResultKeyboard":     b.NewResultKeyboard(id),
+		"NewResultSizeKeyboard": b.NewResultSizeKeyboard(id),
+		"NewResultExtKeyboard":  b.NewResultExtKeyboard(id),
+	}
+
+	for name, keyboard := range keyboards {
+		t.Run(name, func(t *testing.T) {
+			for _, row := range keyboard.InlineKeyboard {
+				for _, button := range row {
+					if !matchesAny(button.CallbackData, callbacks) {
+						t.Errorf("callback %q of the button %q doesn't match any of the result callbacks",
+							button.CallbackData, button.Text)
+					}
+				}
+			}
+		})
+	}
+}
+
+func newTestBuilder() *Builder {
+	return NewBuilder(language.English, message.NewPrinter(language.English), Limits{}, nil)
+}
+
+func matchesAny(data string, patterns []string) bool {
+	for _, p := range patterns {
+		if regexp.MustCompile(fmt.Sprintf("^%s$", p)).MatchString(data) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package menu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+// NewRootView creates root view of the menu. Its text
+// summarizes the current values of the config.
+func (b *Builder) NewRootView(c primitive.Config) View {
+	alpha := b.AutoButton
+	if c.Alpha != 0 {
+		alpha = strconv.Itoa(c.Alpha)
+	}
+
+	lines := []string{
+		b.RootMenu,
+		"",
+		fmt.Sprintf("%s: %s", b.ShapesButton, b.ShapeSetNames(c.Shapes)),
+		fmt.Sprintf("%s: %d", b.IterButton, c.Iterations),
+		fmt.Sprintf("%s: %d", b.RepButton, c.Repeat),
+		fmt.Sprintf("%s: %s", b.AlphaButton, alpha),
+		fmt.Sprintf("%s: %s", b.ExtButton, c.Extension),
+		fmt.Sprintf("%s: %d", b.SizeButton, c.OutputSize),
+	}
+
+	// optional values are shown only when they are set
+	if c.TimeLimit > 0 {
+		lines = append(lines, fmt.Sprintf("%s: %s", b.TimeButton, formatDuration(c.TimeLimit)))
+	}
+	if c.TargetScore > 0 {
+		lines = append(lines, fmt.Sprintf("%s: %g%%", b.ScoreButton, c.TargetScore))
+	}
+	if c.MinImprovement > 0 {
+		lines = append(lines, fmt.Sprintf("%s: %g%%", b.PlateauButton, c.MinImprovement))
+	}
+	if c.Schedule != "" {
+		lines = append(lines, fmt.Sprintf("%s: %s", b.ScheduleButton, c.Schedule))
+	}
+
+	return View{
+		Text:     strings.Join(lines, "\n"),
+		Keyboard: b.RootViewTmpl.Keyboard,
+	}
+}
+
+// formatDuration returns the duration without
+// zero minutes and seconds, e.g. 5m instead of 5m0s.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+package menu
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+func TestNewRootView(t *testing.T) {
+	b := newTestBuilder()
+
+	tests := []struct {
+		name     string
+		config   primitive.Config
+		expected string
+	}{
+		{
+			name:   "Default config",
+			config: primitive.New(1),
+			expected: "Current settings:\n\n" +
+				"Shapes: All\nSteps: 200\nRepetitions: 1\nAlpha: 128\nExtension: jpg\nSize: 1280",
+		},
+		{
+			name: "Config with optional values",
+			config: primitive.Config{
+				Shapes:         primitive.NewShapeSet(primitive.ShapeTriangle, primitive.ShapeCircle),
+				Iterations:     1000,
+				Repeat:         2,
+				Extension:      "png",
+				OutputSize:     256,
+				TimeLimit:      5 * time.Minute,
+				TargetScore:    95,
+				MinImprovement: 0.05,
+				Schedule:       "1-100:a=255",
+			},
+			expected: "Current settings:\n\n" +
+				"Shapes: Triangles, Circles\nSteps: 1000\nRepetitions: 2\nAlpha: Auto\nExtension: png\nSize: 256\n" +
+				"Time Limit: 5m\nTarget Similarity: 95%\nMin Improvement: 0.05%\nSchedule: 1-100:a=255",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := b.NewRootView(tt.config)
+			if res.Text != tt.expected {
+				t.Errorf("Got menu view text: %q;\n want: %q", res.Text, tt.expected)
+			}
+			if !reflect.DeepEqual(res.Keyboard, b.RootViewTmpl.Keyboard) {
+				t.Errorf("Got Keyboard: %+v;\n want: %+v", res.Keyboard, b.RootViewTmpl.Keyboard)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:             "30s",
+		90 * time.Second:             "1m30s",
+		5 * time.Minute:              "5m",
+		time.Hour:                    "1h",
+		time.Hour + 30*time.Minute:   "1h30m",
+		time.Hour + 30*time.Second:   "1h0m30s",
+		2*time.Hour + 10*time.Minute: "2h10m",
+	}
+
+	for d, expected := range tests {
+		if res := formatDuration(d); res != expected {
+			t.Errorf("formatDuration(%s) = %s; want %s", d, res, expected)
+		}
+	}
+}
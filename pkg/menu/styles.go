@@ -0,0 +1,40 @@
+package menu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lazy-void/primitive-bot/pkg/styles"
+	"github.com/lazy-void/primitive-bot/pkg/tg"
+)
+
+// initStyles initializes the template of the view with the styles.
+// Names and descriptions of the styles are shown in the language
+// of the builder.
+func (b *Builder) initStyles(list []styles.Style) {
+	lines := make([]string, len(list))
+	var keyboard [][]tg.InlineKeyboardButton
+	for i, s := range list {
+		name, description := s.Text(b.Lang())
+		lines[i] = fmt.Sprintf("• %s — %s", name, description)
+
+		button := tg.InlineKeyboardButton{
+			Text:         name,
+			CallbackData: fmt.Sprintf("%s/%s", StylesViewCallback, s.ID),
+		}
+		// two buttons in a row
+		if i%2 == 0 {
+			keyboard = append(keyboard, []tg.InlineKeyboardButton{button})
+		} else {
+			keyboard[len(keyboard)-1] = append(keyboard[len(keyboard)-1], button)
+		}
+	}
+	keyboard = append(keyboard, []tg.InlineKeyboardButton{
+		{Text: b.BackButton, CallbackData: RootViewCallback},
+	})
+
+	b.StylesViewTmpl = View{
+		Text:     fmt.Sprintf("%s\n\n%s", b.StylesMenu, strings.Join(lines, "\n")),
+		Keyboard: tg.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	}
+}
@@ -0,0 +1,35 @@
+package menu
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/lazy-void/primitive-bot/pkg/styles"
+)
+
+func TestBuilderStyles(t *testing.T) {
+	list := []styles.Style{
+		{ID: "a", Name: map[string]string{"en": "A", "ru": "А"}, Description: map[string]string{"en": "first"}},
+		{ID: "b", Name: map[string]string{"en": "B"}, Description: map[string]string{"en": "second"}},
+		{ID: "c", Name: map[string]string{"en": "C"}, Description: map[string]string{"en": "third"}},
+	}
+
+	b := NewBuilder(language.Russian, message.NewPrinter(language.Russian), Limits{}, list)
+
+	keyboard := b.StylesViewTmpl.Keyboard.InlineKeyboard
+	if len(keyboard) != 3 || len(keyboard[0]) != 2 || len(keyboard[1]) != 1 {
+		t.Fatalf("Got InlineKeyboard: %+v; want two rows of styles and Back button", keyboard)
+	}
+	if button := keyboard[0][0]; button.Text != "А" || button.CallbackData != "/style/a" {
+		t.Errorf("Got button: %+v; want localized name and callback of the style", button)
+	}
+	if button := keyboard[2][0]; button.CallbackData != RootViewCallback {
+		t.Errorf("Got button: %+v; want Back button", button)
+	}
+	if !strings.Contains(b.StylesViewTmpl.Text, "А — first") || !strings.Contains(b.StylesViewTmpl.Text, "C — third") {
+		t.Errorf("Got menu view text: %s; want names and descriptions of the styles", b.StylesViewTmpl.Text)
+	}
+}
@@ -7,244 +7,167 @@ import (
 	"github.com/lazy-void/primitive-bot/pkg/tg"
 )
 
-var (
-	rootKeyboardTmpl   tg.InlineKeyboardMarkup
-	shapesKeyboardTmpl tg.InlineKeyboardMarkup
-	iterKeyboardTmpl   tg.InlineKeyboardMarkup
-	repKeyboardTmpl    tg.InlineKeyboardMarkup
-	alphaKeyboardTmpl  tg.InlineKeyboardMarkup
-	extKeyboardTmpl    tg.InlineKeyboardMarkup
-	sizeKeyboardTmpl   tg.InlineKeyboardMarkup
-)
-
-// Templates for the different menu views.
-var (
-	RootViewTmpl   View
-	ShapesViewTmpl View
-	IterViewTmpl   View
-	RepViewTmpl    View
-	AlphaViewTmpl  View
-	ExtViewTmpl    View
-	SizeViewTmpl   View
-)
-
-func initKeyboardTemplates() {
-	rootKeyboardTmpl = tg.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tg.InlineKeyboardButton{
-			{
-				{Text: createButtonText, CallbackData: CreateButtonCallback},
-			},
-			{
-				{Text: shapesButtonText, CallbackData: ShapesViewCallback},
-				{Text: iterButtonText, CallbackData: IterViewCallback},
-			},
-			{
-				{Text: repButtonText, CallbackData: RepViewCallback},
-				{Text: alphaButtonText, CallbackData: AlphaViewCallback},
-			},
-			{
-				{Text: extButtonText, CallbackData: ExtViewCallback},
-				{Text: sizeButtonText, CallbackData: SizeViewCallback},
-			},
+// initTemplates initializes the templates of the views.
+func (b *Builder) initTemplates() {
+	// buttons of the options are placed between the shapes
+	// and the stop conditions in the order of the spec
+	settings := []tg.InlineKeyboardButton{{Text: b.ShapesButton, CallbackData: ShapesViewCallback}}
+	settings = append(settings, b.optionButtons(RootViewCallback)...)
+	settings = append(settings,
+		tg.InlineKeyboardButton{Text: b.StopButton, CallbackData: StopViewCallback},
+		tg.InlineKeyboardButton{Text: b.ScheduleButton, CallbackData: ScheduleViewCallback},
+	)
+
+	rootKeyboard := [][]tg.InlineKeyboardButton{
+		{
+			{Text: b.CreateButton, CallbackData: CreateButtonCallback},
+		},
+		{
+			{Text: b.StylesButton, CallbackData: StylesViewCallback},
+			{Text: b.PresetsButton, CallbackData: PresetsViewCallback},
 		},
 	}
+	rootKeyboard = append(rootKeyboard, rows(settings, 2)...)
+	rootKeyboard = append(rootKeyboard,
+		[]tg.InlineKeyboardButton{
+			{Text: b.PinButton, CallbackData: DefaultsPinCallback},
+			{Text: b.ResetButton, CallbackData: DefaultsResetCallback},
+		},
+		[]tg.InlineKeyboardButton{
+			{Text: b.UndoButton, CallbackData: UndoCallback},
+			{Text: b.RedoButton, CallbackData: RedoCallback},
+		},
+	)
+	rootKeyboardTmpl := tg.InlineKeyboardMarkup{InlineKeyboard: rootKeyboard}
 
-	shapesKeyboardTmpl = tg.InlineKeyboardMarkup{
+	stopKeyboard := rows(b.optionButtons(StopViewCallback), 1)
+	stopKeyboard = append(stopKeyboard, []tg.InlineKeyboardButton{
+		{Text: b.BackButton, CallbackData: RootViewCallback},
+	})
+	stopKeyboardTmpl := tg.InlineKeyboardMarkup{InlineKeyboard: stopKeyboard}
+
+	shapesKeyboardTmpl := tg.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tg.InlineKeyboardButton{
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeAny],
+					Text:         b.ShapeNames[primitive.ShapeAny],
 					CallbackData: fmt.Sprintf("%s/0", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeTriangle],
+					Text:         b.ShapeNames[primitive.ShapeTriangle],
 					CallbackData: fmt.Sprintf("%s/1", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapePolygon],
+					Text:         b.ShapeNames[primitive.ShapePolygon],
 					CallbackData: fmt.Sprintf("%s/8", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeRectangle],
+					Text:         b.ShapeNames[primitive.ShapeRectangle],
 					CallbackData: fmt.Sprintf("%s/2", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeRotatedRectangle],
+					Text:         b.ShapeNames[primitive.ShapeRotatedRectangle],
 					CallbackData: fmt.Sprintf("%s/5", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeEllipse],
+					Text:         b.ShapeNames[primitive.ShapeEllipse],
 					CallbackData: fmt.Sprintf("%s/3", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeRotatedEllipse],
+					Text:         b.ShapeNames[primitive.ShapeRotatedEllipse],
 					CallbackData: fmt.Sprintf("%s/7", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeCircle],
+					Text:         b.ShapeNames[primitive.ShapeCircle],
 					CallbackData: fmt.Sprintf("%s/4", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         ShapeNames[primitive.ShapeBezier],
+					Text:         b.ShapeNames[primitive.ShapeBezier],
 					CallbackData: fmt.Sprintf("%s/6", ShapesViewCallback),
 				},
 			},
 			{
 				{
-					Text:         backButtonText,
+					Text:         b.BackButton,
 					CallbackData: RootViewCallback,
 				},
 			},
 		},
 	}
 
-	iterKeyboardTmpl = tg.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tg.InlineKeyboardButton{
-			{
-				{Text: "100", CallbackData: fmt.Sprintf("%s/100", IterViewCallback)},
-				{Text: "200", CallbackData: fmt.Sprintf("%s/200", IterViewCallback)},
-				{Text: "400", CallbackData: fmt.Sprintf("%s/400", IterViewCallback)},
-			},
-			{
-				{Text: "800", CallbackData: fmt.Sprintf("%s/800", IterViewCallback)},
-				{Text: "1000", CallbackData: fmt.Sprintf("%s/1000", IterViewCallback)},
-				{Text: "2000", CallbackData: fmt.Sprintf("%s/2000", IterViewCallback)},
-			},
-			{
-				{Text: OtherButtonText, CallbackData: IterInputCallback},
-			},
-			{
-				{Text: backButtonText, CallbackData: RootViewCallback},
-			},
-		},
-	}
-
-	repKeyboardTmpl = tg.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tg.InlineKeyboardButton{
-			{
-				{Text: "1", CallbackData: fmt.Sprintf("%s/1", RepViewCallback)},
-				{Text: "2", CallbackData: fmt.Sprintf("%s/2", RepViewCallback)},
-				{Text: "3", CallbackData: fmt.Sprintf("%s/3", RepViewCallback)},
-			},
-			{
-				{Text: "4", CallbackData: fmt.Sprintf("%s/4", RepViewCallback)},
-				{Text: "5", CallbackData: fmt.Sprintf("%s/5", RepViewCallback)},
-				{Text: "6", CallbackData: fmt.Sprintf("%s/6", RepViewCallback)},
-			},
-			{
-				{Text: backButtonText, CallbackData: RootViewCallback},
-			},
-		},
-	}
-
-	alphaKeyboardTmpl = tg.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tg.InlineKeyboardButton{
-			{
-				{Text: autoButtonText, CallbackData: fmt.Sprintf("%s/0", AlphaViewCallback)},
-			},
-			{
-				{Text: "32", CallbackData: fmt.Sprintf("%s/32", AlphaViewCallback)},
-				{Text: "64", CallbackData: fmt.Sprintf("%s/64", AlphaViewCallback)},
-				{Text: "128", CallbackData: fmt.Sprintf("%s/128", AlphaViewCallback)},
-				{Text: "255", CallbackData: fmt.Sprintf("%s/255", AlphaViewCallback)},
-			},
-			{
-				{Text: OtherButtonText, CallbackData: AlphaInputCallback},
-			},
-			{
-				{Text: backButtonText, CallbackData: RootViewCallback},
-			},
-		},
-	}
-
-	extKeyboardTmpl = tg.InlineKeyboardMarkup{
+	scheduleKeyboardTmpl := tg.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tg.InlineKeyboardButton{
 			{
-				{Text: "jpg", CallbackData: fmt.Sprintf("%s/jpg", ExtViewCallback)},
-				{Text: "png", CallbackData: fmt.Sprintf("%s/png", ExtViewCallback)},
-				{Text: "svg", CallbackData: fmt.Sprintf("%s/svg", ExtViewCallback)},
-				// gifs are disabled due to performance issues
-				// {Text: "gif", CallbackData: fmt.Sprintf("%s/gif", extButtonCallback)},
+				{Text: b.OffButton, CallbackData: ScheduleOffCallback},
 			},
 			{
-				{Text: backButtonText, CallbackData: RootViewCallback},
-			},
-		},
-	}
-
-	sizeKeyboardTmpl = tg.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tg.InlineKeyboardButton{
-			{
-				{Text: "256", CallbackData: fmt.Sprintf("%s/256", SizeViewCallback)},
-				{Text: "512", CallbackData: fmt.Sprintf("%s/512", SizeViewCallback)},
-				{Text: "720", CallbackData: fmt.Sprintf("%s/720", SizeViewCallback)},
+				{Text: b.EnterButton, CallbackData: ScheduleInputCallback},
 			},
 			{
-				{Text: "1024", CallbackData: fmt.Sprintf("%s/1024", SizeViewCallback)},
-				{Text: "1280", CallbackData: fmt.Sprintf("%s/1280", SizeViewCallback)},
-				{Text: "1920", CallbackData: fmt.Sprintf("%s/1920", SizeViewCallback)},
-			},
-			{
-				{Text: OtherButtonText, CallbackData: SizeInputCallback},
-			},
-			{
-				{Text: backButtonText, CallbackData: RootViewCallback},
+				{Text: b.BackButton, CallbackData: RootViewCallback},
 			},
 		},
 	}
-}
 
-func initViewTemplates() {
-	RootViewTmpl = View{
-		Text:     rootMenuText,
+	b.RootViewTmpl = View{
+		Text:     b.RootMenu,
 		Keyboard: rootKeyboardTmpl,
 	}
 
-	ShapesViewTmpl = View{
-		Text:     shapesMenuText,
+	b.ShapesViewTmpl = View{
+		Text:     b.ShapesMenu,
 		Keyboard: shapesKeyboardTmpl,
 	}
 
-	IterViewTmpl = View{
-		Text:     iterMenuText,
-		Keyboard: iterKeyboardTmpl,
+	b.StopViewTmpl = View{
+		Text:     b.StopMenu,
+		Keyboard: stopKeyboardTmpl,
 	}
 
-	RepViewTmpl = View{
-		Text:     repMenuText,
-		Keyboard: repKeyboardTmpl,
-	}
-
-	AlphaViewTmpl = View{
-		Text:     alphaMenuText,
-		Keyboard: alphaKeyboardTmpl,
+	b.ScheduleViewTmpl = View{
+		Text:     b.ScheduleMenu,
+		Keyboard: scheduleKeyboardTmpl,
 	}
+}
 
-	ExtViewTmpl = View{
-		Text:     extMenuText,
-		Keyboard: extKeyboardTmpl,
+// optionButtons returns the buttons of the options with the parent.
+func (b *Builder) optionButtons(parent string) []tg.InlineKeyboardButton {
+	var buttons []tg.InlineKeyboardButton
+	for _, o := range b.Options {
+		if o.Parent == parent {
+			buttons = append(buttons, o.button())
+		}
 	}
+	return buttons
+}
 
-	SizeViewTmpl = View{
-		Text:     sizeMenuText,
-		Keyboard: sizeKeyboardTmpl,
+// rows splits the buttons into the rows of n buttons.
+func rows(buttons []tg.InlineKeyboardButton, n int) [][]tg.InlineKeyboardButton {
+	var res [][]tg.InlineKeyboardButton
+	for i := 0; i < len(buttons); i += n {
+		end := i + n
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		res = append(res, buttons[i:end])
 	}
+	return res
 }
 
 // NewMenuView creates new View from the template. The second
@@ -282,3 +205,43 @@ func NewMenuView(
 		Keyboard: newKeyboard,
 	}
 }
+
+// NewShapesView creates new ShapesView from the template. The shapes
+// that are in the set will be marked as checked and the others
+// as unchecked.
+func (b *Builder) NewShapesView(shapes primitive.ShapeSet) View {
+	checkedSymbol := "✅"
+	uncheckedSymbol := "⬜"
+
+	view := NewMenuView(b.ShapesViewTmpl, "")
+	for _, row := range view.Keyboard.InlineKeyboard {
+		for j, button := range row {
+			var shape primitive.Shape
+			_, err := fmt.Sscanf(button.CallbackData, ShapesViewCallback+"/%d", &shape)
+			if err != nil {
+				// not a shape button
+				continue
+			}
+
+			symbol := uncheckedSymbol
+			if shapes.Has(shape) {
+				symbol = checkedSymbol
+			}
+			row[j].Text = fmt.Sprintf("%s %s", symbol, button.Text)
+		}
+	}
+
+	return view
+}
+
+// NewScheduleView creates new ScheduleView from the template.
+// If the schedule isn't empty, it is shown in the text of the view.
+func (b *Builder) NewScheduleView(schedule string) View {
+	if schedule == "" {
+		return NewMenuView(b.ScheduleViewTmpl, ScheduleOffCallback)
+	}
+
+	view := NewMenuView(b.ScheduleViewTmpl, ScheduleInputCallback)
+	view.Text = fmt.Sprintf("%s\n\n👉 %s", view.Text, schedule)
+	return view
+}
@@ -3,11 +3,10 @@ package menu
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
-	"golang.org/x/text/language"
-	"golang.org/x/text/message"
-
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
 	"github.com/lazy-void/primitive-bot/pkg/tg"
 )
 
@@ -25,7 +24,7 @@ func copyKeyboard(k tg.InlineKeyboardMarkup) tg.InlineKeyboardMarkup {
 }
 
 func TestNewMenuView(t *testing.T) {
-	InitText(message.NewPrinter(language.English))
+	b := newTestBuilder()
 
 	tests := []struct {
 		name     string
@@ -33,31 +32,19 @@ func TestNewMenuView(t *testing.T) {
 	}{
 		{
 			name:     "RootViewTmpl",
-			template: RootViewTmpl,
+			template: b.RootViewTmpl,
 		},
 		{
 			name:     "ShapeViewTmpl",
-			template: ShapesViewTmpl,
-		},
-		{
-			name:     "IterViewTmpl",
-			template: IterViewTmpl,
-		},
-		{
-			name:     "RepViewTmpl",
-			template: RepViewTmpl,
+			template: b.ShapesViewTmpl,
 		},
 		{
-			name:     "AlphaViewTmpl",
-			template: AlphaViewTmpl,
+			name:     "StopViewTmpl",
+			template: b.StopViewTmpl,
 		},
 		{
-			name:     "ExtViewTmpl",
-			template: ExtViewTmpl,
-		},
-		{
-			name:     "SizeViewTmpl",
-			template: SizeViewTmpl,
+			name:     "ScheduleViewTmpl",
+			template: b.ScheduleViewTmpl,
 		},
 	}
 
@@ -96,12 +83,97 @@ func TestNewMenuView(t *testing.T) {
 }
 
 func TestNewMenuViewWhenGivenIncorrectCallback(t *testing.T) {
-	InitText(message.NewPrinter(language.English))
+	b := newTestBuilder()
 	callback := "some random text"
-	template := RootViewTmpl
+	template := b.RootViewTmpl
 
 	res := NewMenuView(template, callback)
 	if !reflect.DeepEqual(res, template) {
 		t.Errorf("Got menu view: %+v;\n want: %+v", res, template)
 	}
 }
+
+func TestNewShapesView(t *testing.T) {
+	b := newTestBuilder()
+	shapes := primitive.NewShapeSet(primitive.ShapeTriangle, primitive.ShapeBezier)
+
+	res := b.NewShapesView(shapes)
+	expected := copyKeyboard(b.ShapesViewTmpl.Keyboard)
+	for _, row := range expected.InlineKeyboard {
+		for j, button := range row {
+			switch button.CallbackData {
+			case fmt.Sprintf("%s/%d", ShapesViewCallback, primitive.ShapeTriangle),
+				fmt.Sprintf("%s/%d", ShapesViewCallback, primitive.ShapeBezier):
+				row[j].Text = fmt.Sprintf("✅ %s", button.Text)
+			case RootViewCallback:
+			default:
+				row[j].Text = fmt.Sprintf("⬜ %s", button.Text)
+			}
+		}
+	}
+
+	if res.Text != b.ShapesViewTmpl.Text {
+		t.Errorf("Got menu view text: %s; want: %s", res.Text, b.ShapesViewTmpl.Text)
+	}
+	if !reflect.DeepEqual(res.Keyboard, expected) {
+		t.Errorf("Got InlineKeyboard: %+v;\n want: %+v", res.Keyboard, expected)
+	}
+}
+
+func TestNewScheduleView(t *testing.T) {
+	b := newTestBuilder()
+
+	res := b.NewScheduleView("")
+	if expected := NewMenuView(b.ScheduleViewTmpl, ScheduleOffCallback); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Got menu view: %+v;\n want: %+v", res, expected)
+	}
+
+	schedule := "1-100:a=255"
+	res = b.NewScheduleView(schedule)
+	expected := NewMenuView(b.ScheduleViewTmpl, ScheduleInputCallback)
+	if !reflect.DeepEqual(res.Keyboard, expected.Keyboard) {
+		t.Errorf("Got InlineKeyboard: %+v;\n want: %+v", res.Keyboard, expected.Keyboard)
+	}
+	if !strings.HasPrefix(res.Text, b.ScheduleViewTmpl.Text) || !strings.HasSuffix(res.Text, schedule) {
+		t.Errorf("Got menu view text: %s; want template text with the schedule", res.Text)
+	}
+}
+
+func TestNewPresetsView(t *testing.T) {
+	b := newTestBuilder()
+
+	res := b.NewPresetsView(nil)
+	if res.Text != b.PresetsEmptyMenu || len(res.Keyboard.InlineKeyboard) != 1 {
+		t.Errorf("Got menu view: %+v; want empty presets view", res)
+	}
+
+	res = b.NewPresetsView([]string{"a", "b"})
+	expected := [][]tg.InlineKeyboardButton{
+		{{Text: "a", CallbackData: "/preset/a"}, {Text: "🗑", CallbackData: "/preset/a/delete"}},
+		{{Text: "b", CallbackData: "/preset/b"}, {Text: "🗑", CallbackData: "/preset/b/delete"}},
+		{{Text: b.BackButton, CallbackData: RootViewCallback}},
+	}
+	if res.Text != b.PresetsMenu {
+		t.Errorf("Got menu view text: %s; want: %s", res.Text, b.PresetsMenu)
+	}
+	if !reflect.DeepEqual(res.Keyboard.InlineKeyboard, expected) {
+		t.Errorf("Got InlineKeyboard: %+v;\n want: %+v", res.Keyboard.InlineKeyboard, expected)
+	}
+}
+
+func TestNewInputView(t *testing.T) {
+	b := newTestBuilder()
+
+	res := b.NewInputView("Enter the schedule:")
+	expected := View{
+		Text: "Enter the schedule:",
+		Keyboard: tg.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tg.InlineKeyboardButton{
+				{{Text: b.CancelButton, CallbackData: InputCancelCallback}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Got menu view: %+v;\n want: %+v", res, expected)
+	}
+}
@@ -1,71 +1,148 @@
 package menu
 
 import (
+	"strings"
+
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
 	"golang.org/x/text/message"
 )
 
-// ShapeNames contains mapping of shapes to their string representation.
-var ShapeNames map[primitive.Shape]string
+// Texts contains the localized text of the menu.
+type Texts struct {
+	// ShapeNames contains mapping of shapes to their string representation.
+	ShapeNames map[primitive.Shape]string
 
-var (
-	rootMenuText   string
-	shapesMenuText string
-	iterMenuText   string
-	repMenuText    string
-	alphaMenuText  string
-	extMenuText    string
-	sizeMenuText   string
-)
+	// Text of the menu views.
+	RootMenu         string
+	ShapesMenu       string
+	IterMenu         string
+	RepMenu          string
+	AlphaMenu        string
+	ExtMenu          string
+	SizeMenu         string
+	StopMenu         string
+	TimeMenu         string
+	ScoreMenu        string
+	PlateauMenu      string
+	ScheduleMenu     string
+	StylesMenu       string
+	PresetsMenu      string
+	ExpiredMenu      string
+	PresetsEmptyMenu string
+	LanguageMenu     string
 
-// Text of buttons in the menu.
-var (
-	createButtonText string
-	backButtonText   string
-	shapesButtonText string
-	iterButtonText   string
-	repButtonText    string
-	alphaButtonText  string
-	extButtonText    string
-	sizeButtonText   string
-	autoButtonText   string
-	OtherButtonText  string
-)
+	// LanguageName is the name of the language in this language.
+	LanguageName string
+
+	// Text of buttons in the menu.
+	CreateButton   string
+	BackButton     string
+	ShapesButton   string
+	IterButton     string
+	RepButton      string
+	AlphaButton    string
+	ExtButton      string
+	SizeButton     string
+	AutoButton     string
+	StopButton     string
+	TimeButton     string
+	ScoreButton    string
+	PlateauButton  string
+	OffButton      string
+	ResizeButton   string
+	FormatButton   string
+	ScheduleButton string
+	StylesButton   string
+	PresetsButton  string
+	PinButton      string
+	ResetButton    string
+	ReopenButton   string
+	CancelButton   string
+	UndoButton     string
+	RedoButton     string
+	EnterButton    string
+	OtherButton    string
+
+	// MoreButtons maps ContinueSteps to the text of the buttons.
+	MoreButtons map[int]string
+}
+
+// NewTexts returns the text of the menu translated by the printer.
+func NewTexts(p *message.Printer) Texts {
+	t := Texts{
+		ShapeNames: map[primitive.Shape]string{
+			primitive.ShapeAny:              p.Sprintf("All"),
+			primitive.ShapeTriangle:         p.Sprintf("Triangles"),
+			primitive.ShapeRectangle:        p.Sprintf("Rectangles"),
+			primitive.ShapeRotatedRectangle: p.Sprintf("Rotated Rectangles"),
+			primitive.ShapeCircle:           p.Sprintf("Circles"),
+			primitive.ShapeEllipse:          p.Sprintf("Ellipses"),
+			primitive.ShapeRotatedEllipse:   p.Sprintf("Rotated Ellipses"),
+			primitive.ShapePolygon:          p.Sprintf("Quadrilaterals"),
+			primitive.ShapeBezier:           p.Sprintf("Bezier Curves"),
+		},
+		CreateButton:   p.Sprintf("Create"),
+		BackButton:     p.Sprintf("Back"),
+		ShapesButton:   p.Sprintf("Shapes"),
+		IterButton:     p.Sprintf("Steps"),
+		RepButton:      p.Sprintf("Repetitions"),
+		AlphaButton:    p.Sprintf("Alpha"),
+		ExtButton:      p.Sprintf("Extension"),
+		SizeButton:     p.Sprintf("Size"),
+		AutoButton:     p.Sprintf("Auto"),
+		StopButton:     p.Sprintf("Stop Conditions"),
+		TimeButton:     p.Sprintf("Time Limit"),
+		ScoreButton:    p.Sprintf("Target Similarity"),
+		PlateauButton:  p.Sprintf("Min Improvement"),
+		OffButton:      p.Sprintf("Off"),
+		ResizeButton:   p.Sprintf("Resize"),
+		FormatButton:   p.Sprintf("Change Format"),
+		ScheduleButton: p.Sprintf("Schedule"),
+		StylesButton:   p.Sprintf("Styles"),
+		PresetsButton:  p.Sprintf("Presets"),
+		PinButton:      p.Sprintf("Remember as Defaults"),
+		ResetButton:    p.Sprintf("Reset to Defaults"),
+		ReopenButton:   p.Sprintf("Reopen"),
+		CancelButton:   p.Sprintf("Cancel"),
+		UndoButton:     p.Sprintf("↩ Undo"),
+		RedoButton:     p.Sprintf("Redo ↪"),
+		EnterButton:    p.Sprintf("Enter"),
+		OtherButton:    p.Sprintf("Other"),
 
-// InitText initializes all global variables that contain text
-// and also keyboard and view templates.
-func InitText(p *message.Printer) {
-	ShapeNames = map[primitive.Shape]string{
-		primitive.ShapeAny:              p.Sprintf("All"),
-		primitive.ShapeTriangle:         p.Sprintf("Triangles"),
-		primitive.ShapeRectangle:        p.Sprintf("Rectangles"),
-		primitive.ShapeRotatedRectangle: p.Sprintf("Rotated Rectangles"),
-		primitive.ShapeCircle:           p.Sprintf("Circles"),
-		primitive.ShapeEllipse:          p.Sprintf("Ellipses"),
-		primitive.ShapeRotatedEllipse:   p.Sprintf("Rotated Ellipses"),
-		primitive.ShapePolygon:          p.Sprintf("Quadrilaterals"),
-		primitive.ShapeBezier:           p.Sprintf("Bezier Curves"),
+		RootMenu:         p.Sprintf("Current settings:"),
+		ShapesMenu:       p.Sprintf("Select the shapes to be used to create the image. At each step, the best shape is chosen among the selected ones:"),
+		IterMenu:         p.Sprintf("Select the number of steps. Shapes will be drawn at each step:"),
+		RepMenu:          p.Sprintf("Select the number of shapes to draw in each step:"),
+		AlphaMenu:        p.Sprintf("Select an alpha-channel value for the shapes:"),
+		ExtMenu:          p.Sprintf("Select an extension of the resulting image:"),
+		SizeMenu:         p.Sprintf("Select a size for the larger side of the resulting image (the aspect ratio will be preserved):"),
+		StopMenu:         p.Sprintf("Rendering stops after all steps are done or when one of the conditions is met:"),
+		TimeMenu:         p.Sprintf("Select the maximum time that can be spent on creating the image:"),
+		ScoreMenu:        p.Sprintf("Select the similarity with the original image at which the rendering stops:"),
+		PlateauMenu:      p.Sprintf("Select the minimal improvement of the similarity per step. The rendering stops when the image stops improving:"),
+		ScheduleMenu:     p.Sprintf("schedule menu"),
+		StylesMenu:       p.Sprintf("Select a style. It sets several parameters at once, you can change them afterwards:"),
+		PresetsMenu:      p.Sprintf("Select a preset to apply your saved settings. Save the current settings with the command /save <name>:"),
+		ExpiredMenu:      p.Sprintf("This menu has expired."),
+		PresetsEmptyMenu: p.Sprintf("You don't have any presets yet. Save the current settings with the command /save <name>."),
+		LanguageMenu:     p.Sprintf("Select the language:"),
+		LanguageName:     p.Sprintf("English"),
 	}
 
-	createButtonText = p.Sprintf("Create")
-	backButtonText = p.Sprintf("Back")
-	shapesButtonText = p.Sprintf("Shapes")
-	iterButtonText = p.Sprintf("Steps")
-	repButtonText = p.Sprintf("Repetitions")
-	alphaButtonText = p.Sprintf("Alpha")
-	extButtonText = p.Sprintf("Extension")
-	sizeButtonText = p.Sprintf("Size")
-	autoButtonText = p.Sprintf("Auto")
-	OtherButtonText = p.Sprintf("Other")
+	t.MoreButtons = make(map[int]string, len(ContinueSteps))
+	for _, n := range ContinueSteps {
+		t.MoreButtons[n] = p.Sprintf("+%d steps", n)
+	}
 
-	rootMenuText = p.Sprintf("Menu:")
-	shapesMenuText = p.Sprintf("Select the shapes to be used to create the image:")
-	iterMenuText = p.Sprintf("Select the number of steps. Shapes will be drawn at each step:")
-	repMenuText = p.Sprintf("Select the number of shapes to draw in each step:")
-	alphaMenuText = p.Sprintf("Select an alpha-channel value for the shapes:")
-	extMenuText = p.Sprintf("Select an extension of the resulting image:")
-	sizeMenuText = p.Sprintf("Select a size for the larger side of the resulting image (the aspect ratio will be preserved):")
+	return t
+}
 
-	initKeyboardTemplates()
-	initViewTemplates()
+// ShapeSetNames returns names of the shapes in the set separated by commas.
+func (t Texts) ShapeSetNames(s primitive.ShapeSet) string {
+	shapes := s.Shapes()
+	names := make([]string, len(shapes))
+	for i, shape := range shapes {
+		names[i] = t.ShapeNames[shape]
+	}
+	return strings.Join(names, ", ")
 }
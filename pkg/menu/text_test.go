@@ -7,21 +7,21 @@ import (
 	"golang.org/x/text/message"
 )
 
-func TestInitTextInitializesShapeNames(t *testing.T) {
-	InitText(message.NewPrinter(language.English))
+func TestNewTextsInitializesShapeNames(t *testing.T) {
+	texts := NewTexts(message.NewPrinter(language.English))
 
-	if len(ShapeNames) != 9 {
-		t.Errorf("ShapeNames length is %d; want %d", len(ShapeNames), 9)
+	if len(texts.ShapeNames) != 9 {
+		t.Errorf("ShapeNames length is %d; want %d", len(texts.ShapeNames), 9)
 	}
-	for i, v := range ShapeNames {
+	for i, v := range texts.ShapeNames {
 		if v == "" {
 			t.Errorf("ShapeNames[%v] is empty string.", i)
 		}
 	}
 }
 
-func TestInitTextInitializesViewTemplates(t *testing.T) {
-	InitText(message.NewPrinter(language.English))
+func TestNewBuilderInitializesViewTemplates(t *testing.T) {
+	b := newTestBuilder()
 
 	tests := []struct {
 		name     string
@@ -29,31 +29,19 @@ func TestInitTextInitializesViewTemplates(t *testing.T) {
 	}{
 		{
 			name:     "Initializes RootViewTmpl",
-			template: RootViewTmpl,
+			template: b.RootViewTmpl,
 		},
 		{
 			name:     "Initializes ShapeViewTmpl",
-			template: ShapesViewTmpl,
+			template: b.ShapesViewTmpl,
 		},
 		{
-			name:     "Initializes IterViewTmpl",
-			template: IterViewTmpl,
+			name:     "Initializes StopViewTmpl",
+			template: b.StopViewTmpl,
 		},
 		{
-			name:     "Initializes RepViewTmpl",
-			template: RepViewTmpl,
-		},
-		{
-			name:     "Initializes AlphaViewTmpl",
-			template: AlphaViewTmpl,
-		},
-		{
-			name:     "Initializes ExtViewTmpl",
-			template: ExtViewTmpl,
-		},
-		{
-			name:     "Initializes SizeViewTmpl",
-			template: SizeViewTmpl,
+			name:     "Initializes ScheduleViewTmpl",
+			template: b.ScheduleViewTmpl,
 		},
 	}
 
@@ -73,3 +61,23 @@ func TestInitTextInitializesViewTemplates(t *testing.T) {
 		})
 	}
 }
+
+func TestNewBuilderInitializesOptions(t *testing.T) {
+	b := newTestBuilder()
+
+	if len(b.Options) == 0 {
+		t.Fatal("Options are empty.")
+	}
+	for _, o := range b.Options {
+		if o.Button == "" || o.Text == "" {
+			t.Errorf("Option %s has empty text: %+v", o.Name, o)
+		}
+		for _, row := range o.Template().Keyboard.InlineKeyboard {
+			for _, button := range row {
+				if button.Text == "" {
+					t.Errorf("Button with the callback %v has empty text", button.CallbackData)
+				}
+			}
+		}
+	}
+}
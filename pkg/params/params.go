@@ -0,0 +1,259 @@
+// Package params implements parsing of the compact text syntax
+// for the parameters of the operations, e.g. "shape=ellipse steps=800".
+package params
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+// ErrorKind describes what is wrong with the parameter.
+type ErrorKind int
+
+// Possible kinds of the errors.
+const (
+	// ErrSyntax means that the parameter isn't in the form key=value.
+	ErrSyntax ErrorKind = iota
+	// ErrUnknownKey means that there is no parameter with such key.
+	ErrUnknownKey
+	// ErrIncorrectValue means that the value can't be parsed.
+	ErrIncorrectValue
+	// ErrOutOfRange means that the value is outside of Min and Max.
+	ErrOutOfRange
+)
+
+// Error is returned by Parse. It contains everything that
+// is needed to describe the problem to the user.
+type Error struct {
+	Kind  ErrorKind
+	Key   string
+	Value string
+	// Min and Max are set for ErrOutOfRange.
+	Min, Max string
+}
+
+func (e *Error) Error() string {
+	switch e.Kind {
+	case ErrSyntax:
+		return fmt.Sprintf("incorrect parameter %q", e.Value)
+	case ErrUnknownKey:
+		return fmt.Sprintf("unknown parameter %q", e.Key)
+	case ErrOutOfRange:
+		return fmt.Sprintf("value of %s must be from %s to %s", e.Key, e.Min, e.Max)
+	default:
+		return fmt.Sprintf("incorrect value %q of %s", e.Value, e.Key)
+	}
+}
+
+// Limits contains the limits set by the operator.
+type Limits struct {
+	MaxIter int
+	MaxSize int
+	// MaxTime is the max value of the time limit, zero means no limit.
+	MaxTime time.Duration
+}
+
+// shapeNames maps names of the shapes to the shapes.
+var shapeNames = map[string]primitive.Shape{
+	"any":        primitive.ShapeAny,
+	"all":        primitive.ShapeAny,
+	"triangle":   primitive.ShapeTriangle,
+	"rect":       primitive.ShapeRectangle,
+	"rectangle":  primitive.ShapeRectangle,
+	"ellipse":    primitive.ShapeEllipse,
+	"circle":     primitive.ShapeCircle,
+	"rotrect":    primitive.ShapeRotatedRectangle,
+	"bezier":     primitive.ShapeBezier,
+	"rotellipse": primitive.ShapeRotatedEllipse,
+	"polygon":    primitive.ShapePolygon,
+	"quad":       primitive.ShapePolygon,
+}
+
+// shapeKeywords contains canonical names of the shapes used by Format.
+var shapeKeywords = map[primitive.Shape]string{
+	primitive.ShapeAny:              "any",
+	primitive.ShapeTriangle:         "triangle",
+	primitive.ShapeRectangle:        "rect",
+	primitive.ShapeEllipse:          "ellipse",
+	primitive.ShapeCircle:           "circle",
+	primitive.ShapeRotatedRectangle: "rotrect",
+	primitive.ShapeBezier:           "bezier",
+	primitive.ShapeRotatedEllipse:   "rotellipse",
+	primitive.ShapePolygon:          "polygon",
+}
+
+// Parse applies parameters from the text to the config c. The text
+// consists of the pairs key=value separated by whitespace. Keys that
+// aren't specified keep their values from c. If the text is incorrect,
+// the returned error will be of type *Error.
+func Parse(text string, c primitive.Config, l Limits) (primitive.Config, error) {
+	for _, field := range strings.Fields(text) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return c, &Error{Kind: ErrSyntax, Value: field}
+		}
+		key, value := strings.ToLower(parts[0]), parts[1]
+
+		var err error
+		switch key {
+		case "shape", "shapes":
+			c.Shapes, err = parseShapes(key, value)
+		case "steps", "iter":
+			c.Iterations, err = parseInt(key, value, 1, l.MaxIter)
+		case "rep", "repeat":
+			c.Repeat, err = parseInt(key, value, primitive.MinRepeat, primitive.MaxRepeat)
+		case "alpha":
+			if strings.ToLower(value) == "auto" {
+				c.Alpha = 0
+				break
+			}
+			c.Alpha, err = parseInt(key, value, 0, 255)
+		case "size":
+			c.OutputSize, err = parseInt(key, value, 256, l.MaxSize)
+		case "ext", "format":
+			c.Extension, err = parseExtension(key, value)
+		case "time":
+			c.TimeLimit, err = parseTime(key, value, l.MaxTime)
+		case "score":
+			c.TargetScore, err = parseFloat(key, value, 0, 99)
+		case "improvement":
+			c.MinImprovement, err = parseFloat(key, value, 0, 100)
+		case "schedule":
+			c.Schedule, err = parseSchedule(key, value)
+		default:
+			return c, &Error{Kind: ErrUnknownKey, Key: key, Value: value}
+		}
+		if err != nil {
+			return c, err
+		}
+	}
+
+	return c, nil
+}
+
+// Format returns parameters of the config in the syntax accepted by Parse.
+func Format(c primitive.Config) string {
+	shapes := c.Shapes.Shapes()
+	names := make([]string, len(shapes))
+	for i, s := range shapes {
+		names[i] = shapeKeywords[s]
+	}
+	if c.Shapes == primitive.NewShapeSet(primitive.ShapeAny) {
+		names = []string{shapeKeywords[primitive.ShapeAny]}
+	}
+
+	params := []string{
+		fmt.Sprintf("shape=%s", strings.Join(names, "+")),
+		fmt.Sprintf("steps=%d", c.Iterations),
+		fmt.Sprintf("rep=%d", c.Repeat),
+		fmt.Sprintf("alpha=%d", c.Alpha),
+		fmt.Sprintf("size=%d", c.OutputSize),
+		fmt.Sprintf("ext=%s", c.Extension),
+	}
+	if c.TimeLimit > 0 {
+		params = append(params, fmt.Sprintf("time=%s", c.TimeLimit))
+	}
+	if c.TargetScore > 0 {
+		params = append(params, fmt.Sprintf("score=%g", c.TargetScore))
+	}
+	if c.MinImprovement > 0 {
+		params = append(params, fmt.Sprintf("improvement=%g", c.MinImprovement))
+	}
+	if c.Schedule != "" {
+		params = append(params, fmt.Sprintf("schedule=%s", c.Schedule))
+	}
+
+	return strings.Join(params, " ")
+}
+
+func parseShapes(key, value string) (primitive.ShapeSet, error) {
+	var shapes []primitive.Shape
+	for _, name := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return r == '+' || r == ','
+	}) {
+		// plural forms are accepted too
+		shape, ok := shapeNames[strings.TrimSuffix(name, "s")]
+		if !ok {
+			return 0, &Error{Kind: ErrIncorrectValue, Key: key, Value: value}
+		}
+		shapes = append(shapes, shape)
+	}
+	if len(shapes) == 0 {
+		return 0, &Error{Kind: ErrIncorrectValue, Key: key, Value: value}
+	}
+
+	return primitive.NewShapeSet(shapes...), nil
+}
+
+func parseInt(key, value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &Error{Kind: ErrIncorrectValue, Key: key, Value: value}
+	}
+	if n < min || n > max {
+		return 0, &Error{Kind: ErrOutOfRange, Key: key, Value: value,
+			Min: strconv.Itoa(min), Max: strconv.Itoa(max)}
+	}
+	return n, nil
+}
+
+func parseFloat(key, value string, min, max float64) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, &Error{Kind: ErrIncorrectValue, Key: key, Value: value}
+	}
+	if n < min || n > max {
+		return 0, &Error{Kind: ErrOutOfRange, Key: key, Value: value,
+			Min: fmt.Sprint(min), Max: fmt.Sprint(max)}
+	}
+	return n, nil
+}
+
+func parseExtension(key, value string) (string, error) {
+	ext := strings.ToLower(value)
+	switch ext {
+	case "jpg", "png", "svg", "gif", "apng", "json":
+		return ext, nil
+	case "jpeg":
+		return "jpg", nil
+	}
+	return "", &Error{Kind: ErrIncorrectValue, Key: key, Value: value}
+}
+
+// parseTime parses the time limit. The value can be a duration
+// like "5m" or the number of seconds, "off" means no limit.
+func parseTime(key, value string, max time.Duration) (time.Duration, error) {
+	if strings.ToLower(value) == "off" {
+		value = "0"
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, &Error{Kind: ErrIncorrectValue, Key: key, Value: value}
+		}
+		d = time.Duration(n) * time.Second
+	}
+	if d < 0 || (max > 0 && d > max) {
+		return 0, &Error{Kind: ErrOutOfRange, Key: key, Value: value,
+			Min: "0s", Max: max.String()}
+	}
+	return d, nil
+}
+
+func parseSchedule(key, value string) (string, error) {
+	if strings.ToLower(value) == "off" {
+		return "", nil
+	}
+
+	schedule, err := primitive.ParseSchedule(value)
+	if err != nil {
+		return "", &Error{Kind: ErrIncorrectValue, Key: key, Value: value}
+	}
+	return schedule.String(), nil
+}
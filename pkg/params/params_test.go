@@ -0,0 +1,122 @@
+package params
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+var limits = Limits{MaxIter: 2000, MaxSize: 3840, MaxTime: 10 * time.Minute}
+
+func TestParse(t *testing.T) {
+	c := primitive.New(1)
+	res, err := Parse("shape=ellipse steps=800 alpha=64  size=1920 ext=png", c, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := c
+	expected.Shapes = primitive.NewShapeSet(primitive.ShapeEllipse)
+	expected.Iterations = 800
+	expected.Alpha = 64
+	expected.OutputSize = 1920
+	expected.Extension = "png"
+	if res != expected {
+		t.Errorf("got config %+v;\n want %+v", res, expected)
+	}
+}
+
+func TestParseAllKeys(t *testing.T) {
+	tests := []struct {
+		text   string
+		modify func(c *primitive.Config)
+	}{
+		{"shapes=Circles+triangles", func(c *primitive.Config) {
+			c.Shapes = primitive.NewShapeSet(primitive.ShapeCircle, primitive.ShapeTriangle)
+		}},
+		{"shape=all", func(c *primitive.Config) { c.Shapes = primitive.NewShapeSet(primitive.ShapeAny) }},
+		{"rep=1", func(c *primitive.Config) { c.Repeat = 1 }},
+		{"repeat=6", func(c *primitive.Config) { c.Repeat = 6 }},
+		{"alpha=auto", func(c *primitive.Config) { c.Alpha = 0 }},
+		{"format=jpeg", func(c *primitive.Config) { c.Extension = "jpg" }},
+		{"time=5m", func(c *primitive.Config) { c.TimeLimit = 5 * time.Minute }},
+		{"time=90", func(c *primitive.Config) { c.TimeLimit = 90 * time.Second }},
+		{"score=95", func(c *primitive.Config) { c.TargetScore = 95 }},
+		{"improvement=0.05%", func(c *primitive.Config) { c.MinImprovement = 0.05 }},
+		{"schedule=1-10:a=255;11-:a=64", func(c *primitive.Config) { c.Schedule = "1-10:a=255;11-:a=64" }},
+		{"", func(c *primitive.Config) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			c := primitive.New(1)
+			expected := c
+			tt.modify(&expected)
+
+			res, err := Parse(tt.text, c, limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != expected {
+				t.Errorf("got config %+v;\n want %+v", res, expected)
+			}
+		})
+	}
+}
+
+func TestParseWhenIncorrect(t *testing.T) {
+	tests := []struct {
+		text string
+		kind ErrorKind
+	}{
+		{"steps", ErrSyntax},
+		{"steps=", ErrSyntax},
+		{"color=red", ErrUnknownKey},
+		{"shape=star", ErrIncorrectValue},
+		{"steps=many", ErrIncorrectValue},
+		{"ext=bmp", ErrIncorrectValue},
+		{"schedule=1-10:x=1", ErrIncorrectValue},
+		{"steps=2001", ErrOutOfRange},
+		{"size=100", ErrOutOfRange},
+		{"rep=0", ErrOutOfRange},
+		{"rep=7", ErrOutOfRange},
+		{"alpha=256", ErrOutOfRange},
+		{"time=1h", ErrOutOfRange},
+		{"score=100", ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := Parse(tt.text, primitive.New(1), limits)
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("got error %v; want *Error", err)
+			}
+			if e.Kind != tt.kind {
+				t.Errorf("got error kind %d; want %d", e.Kind, tt.kind)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	c := primitive.New(1)
+	c.Shapes = primitive.NewShapeSet(primitive.ShapeTriangle, primitive.ShapeCircle)
+	c.TimeLimit = 5 * time.Minute
+	c.Schedule = "1-10:a=255"
+
+	text := Format(c)
+	res, err := Parse(text, primitive.New(1), limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != c {
+		t.Errorf("%q: got config %+v;\n want %+v", text, res, c)
+	}
+
+	if text := Format(primitive.New(1)); text != "shape=any steps=200 rep=1 alpha=128 size=1280 ext=jpg" {
+		t.Errorf("got %q for the default config", text)
+	}
+}
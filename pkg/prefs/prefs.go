@@ -0,0 +1,193 @@
+// Package prefs implements storage of the default settings of the users.
+package prefs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lazy-void/primitive-bot/internal/fileutil"
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+// ErrNotFound is returned when the user doesn't have the default settings.
+var ErrNotFound = errors.New("preferences not found")
+
+// Prefs contains the default settings of the user.
+type Prefs struct {
+	// Config is used in the new sessions of the user.
+	Config primitive.Config `json:"config"`
+	// Pinned is true if the config was chosen by the user explicitly,
+	// so it isn't replaced with the last used one.
+	Pinned bool `json:"pinned"`
+	// Lang is the language chosen by the user. If it's empty,
+	// the language of the user's Telegram app is used.
+	Lang string `json:"lang,omitempty"`
+}
+
+// Store keeps preferences of each user in a separate JSON file in the directory.
+type Store struct {
+	dir     string
+	workers int
+	// langs caches the languages chosen by the users, so
+	// the files aren't read on every update of the user
+	langs map[int64]string
+	mu    sync.Mutex
+}
+
+// NewStore initializes new instance of Store. The argument 'workers' is
+// used in the default configs, including the ones returned for the users
+// without preferences.
+func NewStore(dir string, workers int) *Store {
+	return &Store{
+		dir:     dir,
+		workers: workers,
+		langs:   make(map[int64]string),
+	}
+}
+
+// Config returns the default config of the user. If the user
+// doesn't have one, the config with the default values is returned.
+func (s *Store) Config(userID int64) (primitive.Config, error) {
+	p, err := s.Get(userID)
+	if errors.Is(err, ErrNotFound) {
+		return primitive.New(s.workers), nil
+	}
+	return p.Config, err
+}
+
+// Get returns preferences of the user. If the user doesn't
+// have them, the returned error will be ErrNotFound.
+func (s *Store) Get(userID int64) (Prefs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load(userID)
+}
+
+// Remember saves the config as the last used one. It doesn't
+// replace the config that was pinned by the user.
+func (s *Store) Remember(userID int64, c primitive.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.load(userID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if p.Pinned {
+		return nil
+	}
+
+	return s.write(userID, Prefs{Config: c, Lang: p.Lang})
+}
+
+// Pin saves the config as the default one, so it
+// isn't replaced with the last used config.
+func (s *Store) Pin(userID int64, c primitive.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.load(userID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	return s.write(userID, Prefs{Config: c, Pinned: true, Lang: p.Lang})
+}
+
+// Lang returns the language chosen by the user. If the
+// user hasn't chosen one, the empty string is returned.
+func (s *Store) Lang(userID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lang, ok := s.langs[userID]; ok {
+		return lang, nil
+	}
+
+	p, err := s.load(userID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	s.langs[userID] = p.Lang
+	return p.Lang, nil
+}
+
+// SetLang saves the language chosen by the user.
+func (s *Store) SetLang(userID int64, lang string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.load(userID)
+	if errors.Is(err, ErrNotFound) {
+		p = Prefs{Config: primitive.New(s.workers)}
+	} else if err != nil {
+		return err
+	}
+	p.Lang = lang
+
+	if err := s.write(userID, p); err != nil {
+		return err
+	}
+	s.langs[userID] = lang
+	return nil
+}
+
+// Delete removes the default settings of the user, so the default
+// values are used again. The language chosen by the user is kept.
+func (s *Store) Delete(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.load(userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if p.Lang != "" {
+		return s.write(userID, Prefs{Config: primitive.New(s.workers), Lang: p.Lang})
+	}
+
+	err = os.Remove(s.path(userID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// load reads preferences of the user. It must be called with the lock held.
+func (s *Store) load(userID int64) (Prefs, error) {
+	data, err := os.ReadFile(s.path(userID))
+	if errors.Is(err, os.ErrNotExist) {
+		return Prefs{}, ErrNotFound
+	}
+	if err != nil {
+		return Prefs{}, err
+	}
+
+	p := Prefs{Config: primitive.New(s.workers)}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Prefs{}, err
+	}
+	return p, nil
+}
+
+// write replaces preferences of the user. It must be called with the lock held.
+func (s *Store) write(userID int64, p Prefs) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFile(s.path(userID), data)
+}
+
+func (s *Store) path(userID int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%d.prefs.json", userID))
+}
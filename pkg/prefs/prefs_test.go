@@ -0,0 +1,139 @@
+package prefs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+func TestStore_Config(t *testing.T) {
+	workers := 2
+	dir := t.TempDir()
+
+	// defaults are used if the user doesn't have preferences
+	c, err := NewStore(dir, workers).Config(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != primitive.New(workers) {
+		t.Errorf("got config %+v;\n want %+v", c, primitive.New(workers))
+	}
+
+	c.Iterations = 500
+	c.Schedule = "1-10:a=255"
+	if err := NewStore(dir, workers).Remember(1, c); err != nil {
+		t.Fatal(err)
+	}
+
+	// preferences are kept across restarts
+	res, err := NewStore(dir, workers).Config(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != c {
+		t.Errorf("got config %+v;\n want %+v", res, c)
+	}
+}
+
+func TestStore_RememberWhenPinned(t *testing.T) {
+	s := NewStore(t.TempDir(), 1)
+	pinned := primitive.New(1)
+	pinned.Iterations = 100
+	if err := s.Pin(1, pinned); err != nil {
+		t.Fatal(err)
+	}
+
+	last := primitive.New(1)
+	last.Iterations = 500
+	if err := s.Remember(1, last); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := s.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Pinned || p.Config != pinned {
+		t.Errorf("got preferences %+v;\n want pinned config %+v", p, pinned)
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	s := NewStore(t.TempDir(), 1)
+	c := primitive.New(1)
+	c.Iterations = 500
+	if err := s.Pin(1, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v; want %v", err, ErrNotFound)
+	}
+	// deleting missing preferences isn't an error
+	if err := s.Delete(1); err != nil {
+		t.Errorf("got error %v; want nil", err)
+	}
+}
+
+func TestStore_LangIsCached(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir, 1)
+	if err := s.SetLang(1, "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Lang(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the files aren't read again
+	if err := os.WriteFile(filepath.Join(dir, "2.prefs.json"), []byte(`{"lang":"en"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "1.prefs.json")); err != nil {
+		t.Fatal(err)
+	}
+	if lang, err := s.Lang(1); err != nil || lang != "ru" {
+		t.Errorf("Lang(1) = %q, %v; want %q", lang, err, "ru")
+	}
+	if lang, err := s.Lang(2); err != nil || lang != "" {
+		t.Errorf("Lang(2) = %q, %v; want empty language", lang, err)
+	}
+}
+
+func TestStore_Lang(t *testing.T) {
+	s := NewStore(t.TempDir(), 1)
+	if lang, err := s.Lang(1); err != nil || lang != "" {
+		t.Errorf("got language %q and error %v; want empty language", lang, err)
+	}
+
+	if err := s.SetLang(1, "ru"); err != nil {
+		t.Fatal(err)
+	}
+	c := primitive.New(1)
+	c.Iterations = 500
+
+	// the language is kept when the settings are changed
+	if err := s.Remember(1, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pin(1, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := s.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Lang != "ru" || p.Pinned || p.Config != primitive.New(1) {
+		t.Errorf("got preferences %+v; want default settings with the language", p)
+	}
+}
@@ -0,0 +1,190 @@
+// Package presets implements storage of the named configs saved by the users.
+package presets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/internal/fileutil"
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+var (
+	// ErrNotFound is returned when the preset doesn't exist.
+	ErrNotFound = errors.New("preset not found")
+	// ErrLimit is returned when the user already has the maximum number of presets.
+	ErrLimit = errors.New("too many presets")
+	// ErrInvalidName is returned when the name of the preset is incorrect.
+	ErrInvalidName = errors.New("incorrect preset name")
+)
+
+var nameRegex = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,20}$`)
+
+// ValidName reports whether the name can be used as the name of the preset.
+// The name is limited in bytes too, so it fits into the callback data
+// of the buttons, which can't be longer than 64 bytes.
+func ValidName(name string) bool {
+	return len(name) <= 40 && nameRegex.MatchString(name)
+}
+
+// Preset is the named config saved by the user.
+type Preset struct {
+	Name    string           `json:"name"`
+	Config  primitive.Config `json:"config"`
+	Created time.Time        `json:"created"`
+}
+
+// Store keeps presets of each user in a separate JSON file in the directory.
+type Store struct {
+	dir     string
+	workers int
+	limit   int
+	mu      sync.Mutex
+}
+
+// NewStore initializes new instance of Store. The number of workers
+// isn't saved with the presets, so the configs of the presets read from
+// the files get 'workers'. The argument 'limit' specifies the number of presets that each user
+// can save, zero means no limit.
+func NewStore(dir string, workers, limit int) *Store {
+	return &Store{
+		dir:     dir,
+		workers: workers,
+		limit:   limit,
+	}
+}
+
+// Save writes the config under the name to the presets of the user.
+// The preset with the same name is replaced.
+func (s *Store) Save(userID int64, name string, c primitive.Config) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+
+	p := Preset{Name: name, Config: c, Created: time.Now()}
+	if i := index(list, name); i >= 0 {
+		list[i] = p
+	} else {
+		if s.limit > 0 && len(list) >= s.limit {
+			return ErrLimit
+		}
+		list = append(list, p)
+	}
+
+	return s.write(userID, list)
+}
+
+// List returns presets of the user sorted by name.
+func (s *Store) List(userID int64) ([]Preset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load(userID)
+}
+
+// Get returns the preset of the user with the specified name. If the
+// preset doesn't exist, the returned error will be ErrNotFound.
+func (s *Store) Get(userID int64, name string) (Preset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.load(userID)
+	if err != nil {
+		return Preset{}, err
+	}
+
+	i := index(list, name)
+	if i < 0 {
+		return Preset{}, ErrNotFound
+	}
+	return list[i], nil
+}
+
+// Delete removes the preset of the user with the specified name. If the
+// preset doesn't exist, the returned error will be ErrNotFound.
+func (s *Store) Delete(userID int64, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+
+	i := index(list, name)
+	if i < 0 {
+		return ErrNotFound
+	}
+	list = append(list[:i], list[i+1:]...)
+
+	if len(list) == 0 {
+		return os.Remove(s.path(userID))
+	}
+	return s.write(userID, list)
+}
+
+// load reads presets of the user. It must be called with the lock held.
+func (s *Store) load(userID int64) ([]Preset, error) {
+	data, err := os.ReadFile(s.path(userID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	list := make([]Preset, len(raw))
+	for i, r := range raw {
+		list[i].Config = primitive.New(s.workers)
+		if err := json.Unmarshal(r, &list[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// write replaces presets of the user. It must be called with the lock held.
+func (s *Store) write(userID int64, list []Preset) error {
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFile(s.path(userID), data)
+}
+
+func (s *Store) path(userID int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%d.presets.json", userID))
+}
+
+func index(list []Preset, name string) int {
+	for i, p := range list {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
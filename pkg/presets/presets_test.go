@@ -0,0 +1,106 @@
+package presets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+func TestStore_SaveAndGet(t *testing.T) {
+	workers := 2
+	dir := t.TempDir()
+	c := primitive.New(workers)
+	c.Iterations = 500
+	c.Shapes = primitive.NewShapeSet(primitive.ShapeCircle, primitive.ShapeTriangle)
+	c.Schedule = "1-10:a=255"
+
+	if err := NewStore(dir, workers, 0).Save(1, "круги", c); err != nil {
+		t.Fatal(err)
+	}
+
+	// presets are kept across restarts
+	p, err := NewStore(dir, workers, 0).Get(1, "круги")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "круги" || p.Config != c {
+		t.Errorf("got preset %+v;\n want config %+v", p, c)
+	}
+
+	if _, err := NewStore(dir, workers, 0).Get(2, "круги"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v for the preset of another user; want %v", err, ErrNotFound)
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	s := NewStore(t.TempDir(), 1, 0)
+	for _, name := range []string{"b", "c", "a", "b"} {
+		if err := s.Save(1, name, primitive.New(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := s.List(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range list {
+		names = append(names, p.Name)
+	}
+	if len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "c" {
+		t.Errorf("got presets %v; want %v", names, []string{"a", "b", "c"})
+	}
+}
+
+func TestStore_SaveWhenLimitIsReached(t *testing.T) {
+	s := NewStore(t.TempDir(), 1, 2)
+	for _, name := range []string{"a", "b"} {
+		if err := s.Save(1, name, primitive.New(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Save(1, "c", primitive.New(1)); !errors.Is(err, ErrLimit) {
+		t.Errorf("got error %v; want %v", err, ErrLimit)
+	}
+	// existing preset can still be replaced
+	if err := s.Save(1, "a", primitive.New(1)); err != nil {
+		t.Errorf("got error %v when replacing the preset", err)
+	}
+}
+
+func TestStore_SaveWhenNameIsIncorrect(t *testing.T) {
+	s := NewStore(t.TempDir(), 1, 0)
+	for _, name := range []string{"", "two words", "a/b", "very_long_name_of_the_preset", "名前名前名前名前名前名前名前名前"} {
+		if err := s.Save(1, name, primitive.New(1)); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("name %q: got error %v; want %v", name, err, ErrInvalidName)
+		}
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	s := NewStore(t.TempDir(), 1, 0)
+	for _, name := range []string{"a", "b"} {
+		if err := s.Save(1, name, primitive.New(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Delete(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(1, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v for the deleted preset; want %v", err, ErrNotFound)
+	}
+	if err := s.Delete(1, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := s.List(1); err != nil || len(list) != 0 {
+		t.Errorf("got presets %v and error %v; want no presets", list, err)
+	}
+	if err := s.Delete(1, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v; want %v", err, ErrNotFound)
+	}
+}
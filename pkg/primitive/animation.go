@@ -0,0 +1,144 @@
+package primitive
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/fogleman/gg"
+	"github.com/fogleman/primitive/primitive"
+)
+
+// Limits of the animated images.
+const (
+	maxAnimationFrames = 50
+	minAnimationFrames = 5
+	maxAnimationBytes  = 10 << 20 // 10 MiB
+	maxAnimationSize   = 720
+
+	// delays are in 100ths of a second
+	frameDelay     = 20
+	lastFrameDelay = 250
+)
+
+// animationEncoder writes frames of the animation to w.
+type animationEncoder func(w io.Writer, frames []image.Image) error
+
+// saveAnimation renders the step-by-step animation of the model and
+// writes it to the outputPath with the given encoder. The number of frames
+// and then the resolution (which starts from maxAnimationSize) are reduced until the result fits into
+// maxAnimationBytes.
+func saveAnimation(outputPath string, model *primitive.Model, encode animationEncoder) error {
+	numFrames := maxAnimationFrames
+	factor := 1.0
+	if size := math.Max(float64(model.Sw), float64(model.Sh)); size > maxAnimationSize {
+		factor = maxAnimationSize / size
+	}
+
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		frames := renderFrames(model, numFrames, factor)
+		if err := encode(&buf, frames); err != nil {
+			return err
+		}
+
+		if buf.Len() <= maxAnimationBytes {
+			break
+		}
+
+		switch {
+		case numFrames > minAnimationFrames:
+			numFrames /= 2
+			if numFrames < minAnimationFrames {
+				numFrames = minAnimationFrames
+			}
+		case factor > 0.1:
+			factor *= 0.75
+		default:
+			return fmt.Errorf("animation is too big: %d bytes", buf.Len())
+		}
+	}
+
+	return os.WriteFile(filepath.Clean(outputPath), buf.Bytes(), 0600)
+}
+
+// renderFrames draws at most n frames which are evenly sampled from all
+// the steps of the model. The first frame contains only the background and
+// the last one contains all shapes. Factor scales the size of the frames.
+func renderFrames(model *primitive.Model, n int, factor float64) []image.Image {
+	numShapes := len(model.Shapes)
+	if n > numShapes+1 {
+		n = numShapes + 1
+	}
+	if n < 2 {
+		n = 2
+	}
+
+	dc := newContext(model, factor)
+	frames := make([]image.Image, 0, n)
+	frames = append(frames, snapshot(dc))
+
+	drawn := 0
+	for i := 1; i < n; i++ {
+		until := int(math.Round(float64(i*numShapes) / float64(n-1)))
+		for ; drawn < until; drawn++ {
+			c := model.Colors[drawn]
+			dc.SetRGBA255(c.R, c.G, c.B, c.A)
+			model.Shapes[drawn].Draw(dc, model.Scale*factor)
+			dc.Fill()
+		}
+		frames = append(frames, snapshot(dc))
+	}
+
+	return frames
+}
+
+// newContext returns drawing context filled with the background color
+// of the model. Factor scales the size of the context.
+func newContext(model *primitive.Model, factor float64) *gg.Context {
+	w := int(math.Max(1, float64(model.Sw)*factor))
+	h := int(math.Max(1, float64(model.Sh)*factor))
+
+	dc := gg.NewContext(w, h)
+	dc.Scale(model.Scale*factor, model.Scale*factor)
+	dc.Translate(0.5, 0.5)
+	dc.SetColor(model.Background.NRGBA())
+	dc.Clear()
+	return dc
+}
+
+// snapshot returns copy of the current image of the context.
+func snapshot(dc *gg.Context) image.Image {
+	src := dc.Image()
+	dst := image.NewRGBA(src.Bounds())
+	draw.Draw(dst, dst.Rect, src, image.Point{}, draw.Src)
+	return dst
+}
+
+// encodeGIF writes frames as the looped GIF animation. All frames share
+// one palette which is built from the last frame.
+func encodeGIF(w io.Writer, frames []image.Image) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+
+	p := newPaletteMap(quantize(frames[len(frames)-1], 256))
+	g := gif.GIF{
+		Image: make([]*image.Paletted, len(frames)),
+		Delay: make([]int, len(frames)),
+	}
+	for i, src := range frames {
+		g.Image[i] = p.convert(src)
+		g.Delay[i] = frameDelay
+	}
+	g.Delay[len(frames)-1] = lastFrameDelay
+
+	return gif.EncodeAll(w, &g)
+}
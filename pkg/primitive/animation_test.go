@@ -0,0 +1,95 @@
+package primitive
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/fogleman/primitive/primitive"
+)
+
+func newTestModel(t *testing.T, steps int) *primitive.Model {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for x := 0; x < 32; x++ {
+		for y := 0; y < 16; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 8), G: uint8(y * 16), B: 128, A: 255})
+		}
+	}
+
+	bg := primitive.MakeColor(primitive.AverageImageColor(img))
+	model := primitive.NewModel(img, bg, 64, 1)
+	for i := 0; i < steps; i++ {
+		model.Step(primitive.ShapeTypeTriangle, 128, 0)
+	}
+	return model
+}
+
+func TestRenderFrames(t *testing.T) {
+	model := newTestModel(t, 10)
+
+	tests := []struct {
+		name     string
+		n        int
+		factor   float64
+		expected int
+		size     image.Point
+	}{
+		{name: "Less frames than steps", n: 5, factor: 1, expected: 5, size: image.Pt(64, 32)},
+		{name: "More frames than steps", n: 50, factor: 1, expected: len(model.Shapes) + 1, size: image.Pt(64, 32)},
+		{name: "Scaled frames", n: 3, factor: 0.5, expected: 3, size: image.Pt(32, 16)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := renderFrames(model, tt.n, tt.factor)
+			if len(frames) != tt.expected {
+				t.Fatalf("got %d frames; want %d", len(frames), tt.expected)
+			}
+			if size := frames[0].Bounds().Size(); size != tt.size {
+				t.Errorf("got frame size %v; want %v", size, tt.size)
+			}
+		})
+	}
+}
+
+func TestEncodeGIF(t *testing.T) {
+	frames := renderFrames(newTestModel(t, 5), 4, 1)
+
+	var buf bytes.Buffer
+	if err := encodeGIF(&buf, frames); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("couldn't decode gif: %v", err)
+	}
+	if len(g.Image) != len(frames) {
+		t.Errorf("got %d frames; want %d", len(g.Image), len(frames))
+	}
+	if g.Delay[len(g.Delay)-1] != lastFrameDelay {
+		t.Errorf("got last delay %d; want %d", g.Delay[len(g.Delay)-1], lastFrameDelay)
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	img.Set(1, 0, color.RGBA{G: 255, A: 255})
+	img.Set(2, 0, color.RGBA{B: 255, A: 255})
+	img.Set(3, 0, color.RGBA{B: 255, A: 255})
+
+	p := quantize(img, 256)
+	if len(p) != 3 {
+		t.Errorf("got palette of %d colors; want %d", len(p), 3)
+	}
+
+	p = quantize(img, 2)
+	if len(p) != 2 {
+		t.Errorf("got palette of %d colors; want %d", len(p), 2)
+	}
+}
@@ -0,0 +1,146 @@
+package primitive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+)
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// pngChunk is a single chunk of the PNG file.
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// encodeAPNG writes frames as the looped animated PNG. Each frame
+// is encoded with image/png and its image data is moved to the
+// fdAT chunks of the resulting file.
+func encodeAPNG(w io.Writer, frames []image.Image) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+
+	if _, err := io.WriteString(w, pngSignature); err != nil {
+		return err
+	}
+
+	var seq uint32
+	var header []byte
+	for i, frame := range frames {
+		chunks, err := encodePNGChunks(frame)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			header = chunks[0].data
+			if err := writeChunk(w, chunks[0]); err != nil {
+				return err
+			}
+
+			// animation control chunk
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+			binary.BigEndian.PutUint32(actl[4:], 0) // loop forever
+			if err := writeChunk(w, pngChunk{"acTL", actl}); err != nil {
+				return err
+			}
+		} else if !bytes.Equal(header, chunks[0].data) {
+			return fmt.Errorf("frame %d has different header", i)
+		}
+
+		// frame control chunk
+		b := frame.Bounds()
+		delay := frameDelay
+		if i == len(frames)-1 {
+			delay = lastFrameDelay
+		}
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(b.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(b.Dy()))
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+		binary.BigEndian.PutUint16(fctl[22:], 100)
+		if err := writeChunk(w, pngChunk{"fcTL", fctl}); err != nil {
+			return err
+		}
+		seq++
+
+		// frame data
+		for _, c := range chunks[1:] {
+			if c.typ != "IDAT" {
+				continue
+			}
+
+			if i == 0 {
+				err = writeChunk(w, c)
+			} else {
+				data := make([]byte, 4+len(c.data))
+				binary.BigEndian.PutUint32(data, seq)
+				copy(data[4:], c.data)
+				err = writeChunk(w, pngChunk{"fdAT", data})
+				seq++
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeChunk(w, pngChunk{typ: "IEND"})
+}
+
+// encodePNGChunks encodes the image as PNG and splits it into chunks.
+// The first chunk is always IHDR.
+func encodePNGChunks(img image.Image) ([]pngChunk, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	data := buf.Bytes()[len(pngSignature):]
+	var chunks []pngChunk
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data)
+		if uint32(len(data)-12) < length {
+			return nil, fmt.Errorf("png chunk is truncated")
+		}
+
+		chunks = append(chunks, pngChunk{
+			typ:  string(data[4:8]),
+			data: data[8 : 8+length],
+		})
+		data = data[12+length:]
+	}
+
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" {
+		return nil, fmt.Errorf("png doesn't start with IHDR chunk")
+	}
+	return chunks, nil
+}
+
+func writeChunk(w io.Writer, c pngChunk) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(c.data)))
+	copy(header[4:], c.typ)
+
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write(header[4:])
+	_, _ = crc.Write(c.data)
+
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+
+	for _, b := range [][]byte{header[:], c.data, footer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
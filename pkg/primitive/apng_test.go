@@ -0,0 +1,50 @@
+package primitive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"testing"
+)
+
+func TestEncodeAPNG(t *testing.T) {
+	frames := renderFrames(newTestModel(t, 5), 4, 1)
+
+	var buf bytes.Buffer
+	if err := encodeAPNG(&buf, frames); err != nil {
+		t.Fatal(err)
+	}
+
+	// decoders without APNG support must show the first frame
+	if _, err := png.Decode(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("couldn't decode png: %v", err)
+	}
+
+	counts := map[string]int{}
+	data := buf.Bytes()[len(pngSignature):]
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data)
+		typ := string(data[4:8])
+		counts[typ]++
+
+		if typ == "acTL" {
+			if n := binary.BigEndian.Uint32(data[8:]); int(n) != len(frames) {
+				t.Errorf("acTL contains %d frames; want %d", n, len(frames))
+			}
+		}
+		data = data[12+length:]
+	}
+
+	if counts["acTL"] != 1 {
+		t.Errorf("got %d acTL chunks; want 1", counts["acTL"])
+	}
+	if counts["fcTL"] != len(frames) {
+		t.Errorf("got %d fcTL chunks; want %d", counts["fcTL"], len(frames))
+	}
+	if counts["fdAT"] < len(frames)-1 {
+		t.Errorf("got %d fdAT chunks; want at least %d", counts["fdAT"], len(frames)-1)
+	}
+	if counts["IEND"] != 1 {
+		t.Errorf("got %d IEND chunks; want 1", counts["IEND"])
+	}
+}
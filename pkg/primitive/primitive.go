@@ -3,6 +3,8 @@
 package primitive
 
 import (
+	"fmt"
+	"image"
 	"math/rand"
 	"time"
 
@@ -26,15 +28,49 @@ const (
 	ShapePolygon
 )
 
+// plateauWindow is the number of steps over which
+// the improvement of the similarity is averaged.
+const plateauWindow = 10
+
+// MinRepeat and MaxRepeat are the range of Repeat that the users can
+// choose. Each repeat makes the step longer, so it's limited to keep
+// the operations of one user from holding the queue.
+const (
+	MinRepeat = 1
+	MaxRepeat = 6
+)
+
 // Config contains information needed to create primitive image.
 type Config struct {
 	workers    int
 	OutputSize int
-	Shape      Shape
+	Shapes     ShapeSet
 	Iterations int
 	Repeat     int
 	Alpha      int
 	Extension  string
+
+	// Schedule overrides Alpha, Repeat and Shapes on the ranges
+	// of the steps. It is specified in the compact syntax
+	// described in the documentation of the Schedule type.
+	Schedule string
+
+	// Optional stop conditions. Zero value disables the condition.
+	// TargetScore is the similarity with the input image in percents.
+	// MinImprovement is the minimal improvement of the similarity
+	// per step (in percents) that is needed to continue rendering.
+	// TimeLimit is the maximum time spent on rendering.
+	TargetScore    float64
+	MinImprovement float64
+	TimeLimit      time.Duration
+}
+
+// Checkpoint is used to periodically save the state of the rendering,
+// so it can be resumed with Continue. Save is called with the current
+// scene when at least Interval has passed since the previous call.
+type Checkpoint struct {
+	Interval time.Duration
+	Save     func(scene Scene)
 }
 
 // New initializes the instance of Config.
@@ -42,7 +78,7 @@ func New(workers int) Config {
 	return Config{
 		workers:    workers,
 		OutputSize: 1280,
-		Shape:      ShapeAny,
+		Shapes:     NewShapeSet(ShapeAny),
 		Iterations: 200,
 		Repeat:     1,
 		Alpha:      128,
@@ -50,56 +86,181 @@ func New(workers int) Config {
 	}
 }
 
-// Create method creates a primitive image from an image in inputPath
-// and saves result in outputPath.
-func (c Config) Create(inputPath, outputPath string) error {
-	// seed random number generator
-	rand.Seed(time.Now().UTC().UnixNano())
+// Similarity converts the score of the model (difference between
+// the images) to the similarity in percents.
+func Similarity(score float64) float64 {
+	return 100 * (1 - score)
+}
 
-	// read input image
-	input, err := primitive.LoadImage(inputPath)
-	if err != nil {
-		return err
+// shouldStop reports whether any of the optional stop conditions is met.
+// The argument similarities contains similarity after each of the steps.
+func (c Config) shouldStop(similarities []float64, elapsed time.Duration) bool {
+	steps := len(similarities)
+	if steps == 0 {
+		return false
 	}
 
-	// scale down input image if needed
-	size := uint(256)
-	input = resize.Thumbnail(size, size, input, resize.Bilinear)
+	if c.TimeLimit > 0 && elapsed >= c.TimeLimit {
+		return true
+	}
+
+	if c.TargetScore > 0 && similarities[steps-1] >= c.TargetScore {
+		return true
+	}
+
+	if c.MinImprovement > 0 && steps > plateauWindow {
+		improvement := (similarities[steps-1] - similarities[steps-1-plateauWindow]) / plateauWindow
+		if improvement < c.MinImprovement {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FileExtension returns the extension of the file
+// in which the resulting image is saved.
+func (c Config) FileExtension() string {
+	if c.Extension == "apng" {
+		return "png"
+	}
+	return c.Extension
+}
+
+// Create method creates a primitive image from an image in inputPath
+// and saves result in outputPath. It returns the shapes of the created image.
+// Optional checkpoint is used to save the intermediate results.
+func (c Config) Create(inputPath, outputPath string, checkpoint ...Checkpoint) (Scene, error) {
+	input, err := loadInput(inputPath)
+	if err != nil {
+		return Scene{}, err
+	}
 
 	// determine background color
 	bg := primitive.MakeColor(primitive.AverageImageColor(input))
 
-	// run algorithm
 	model := primitive.NewModel(input, bg, c.OutputSize, c.workers)
+	return c.run(model, 0, outputPath, checkpoint)
+}
+
+// Continue method performs more steps on the scene that was created earlier
+// from an image in inputPath and saves result in outputPath. It returns
+// the shapes of the created image including the shapes of the original scene.
+// Optional checkpoint is used to save the intermediate results.
+func (c Config) Continue(inputPath string, scene Scene, outputPath string, checkpoint ...Checkpoint) (Scene, error) {
+	if scene.Version != SceneVersion {
+		return Scene{}, fmt.Errorf("unsupported scene version %d", scene.Version)
+	}
+
+	input, err := loadInput(inputPath)
+	if err != nil {
+		return Scene{}, err
+	}
+
+	// restore the state of the model by adding the shapes in the same order
+	model := primitive.NewModel(input, primitive.MakeHexColor(scene.Background), c.OutputSize, c.workers)
+	for i, ss := range scene.Shapes {
+		shape, err := ss.shape(model.Workers[0])
+		if err != nil {
+			return Scene{}, fmt.Errorf("shape %d: %w", i, err)
+		}
+		model.Add(shape, ss.Alpha)
+	}
+
+	return c.run(model, scene.Steps, outputPath, checkpoint)
+}
+
+// run performs steps of the algorithm on the model and saves result
+// in outputPath. The argument steps is the number of steps that were
+// already performed on the model.
+func (c Config) run(model *primitive.Model, steps int, outputPath string, checkpoint []Checkpoint) (Scene, error) {
+	schedule, err := ParseSchedule(c.Schedule)
+	if err != nil {
+		return Scene{}, err
+	}
+
+	// seed random number generator
+	rand.Seed(time.Now().UTC().UnixNano())
+
+	start := time.Now()
+	lastCheckpoint := start
+	similarities := make([]float64, 0, c.Iterations)
 	for i := 0; i < c.Iterations; i++ {
 		// find optimal shape and add it to the model
-		model.Step(primitive.ShapeType(c.Shape), c.Alpha, c.Repeat)
+		sc := schedule.apply(c, steps+i+1)
+		step(model, sc.Shapes, sc.Alpha, sc.Repeat)
+
+		similarities = append(similarities, Similarity(model.Score))
+		if c.shouldStop(similarities, time.Since(start)) {
+			break
+		}
+
+		if len(checkpoint) > 0 && time.Since(lastCheckpoint) >= checkpoint[0].Interval {
+			scene, err := newScene(model)
+			if err != nil {
+				return Scene{}, err
+			}
+			scene.Steps = steps + len(similarities)
+			checkpoint[0].Save(scene)
+			lastCheckpoint = time.Now()
+		}
+	}
+
+	scene, err := newScene(model)
+	if err != nil {
+		return Scene{}, err
 	}
+	scene.Steps = steps + len(similarities)
 
-	// write output image
+	return scene, c.save(model, scene.Steps, outputPath)
+}
+
+// Render draws shapes of the scene that was created earlier with
+// the OutputSize and Extension of the config and saves result in outputPath.
+func (c Config) Render(scene Scene, outputPath string) error {
+	model, err := scene.model(c.OutputSize)
+	if err != nil {
+		return err
+	}
+
+	return c.save(model, scene.Steps, outputPath)
+}
+
+// loadInput reads the input image and scales it down.
+func loadInput(inputPath string) (image.Image, error) {
+	input, err := primitive.LoadImage(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	size := uint(256)
+	return resize.Thumbnail(size, size, input, resize.Bilinear), nil
+}
+
+// save writes the image of the model to the outputPath. The argument
+// steps is the number of steps performed on the model, which is
+// written to the scene file.
+func (c Config) save(model *primitive.Model, steps int, outputPath string) error {
 	switch c.Extension {
 	case "png":
-		err = primitive.SavePNG(outputPath, model.Context.Image())
-		if err != nil {
-			return err
-		}
+		return primitive.SavePNG(outputPath, model.Context.Image())
 	case "jpg":
-		err = primitive.SaveJPG(outputPath, model.Context.Image(), 95)
-		if err != nil {
-			return err
-		}
+		return primitive.SaveJPG(outputPath, model.Context.Image(), 95)
 	case "svg":
-		err = primitive.SaveFile(outputPath, model.SVG())
-		if err != nil {
-			return err
-		}
+		return primitive.SaveFile(outputPath, model.SVG())
 	case "gif":
-		frames := model.Frames(0.001)
-		err = primitive.SaveGIFImageMagick(outputPath, frames, 50, 250)
+		return saveAnimation(outputPath, model, encodeGIF)
+	case "apng":
+		return saveAnimation(outputPath, model, encodeAPNG)
+	case "json":
+		scene, err := newScene(model)
 		if err != nil {
 			return err
 		}
+		scene.Steps = steps
+
+		return SaveScene(outputPath, scene)
 	}
 
-	return nil
+	return fmt.Errorf("unknown extension %q", c.Extension)
 }
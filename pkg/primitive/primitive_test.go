@@ -1,13 +1,16 @@
 package primitive
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestNew(t *testing.T) {
 	workers := 1
 	expected := Config{
 		workers:    workers,
 		OutputSize: 1280,
-		Shape:      ShapeAny,
+		Shapes:     NewShapeSet(ShapeAny),
 		Iterations: 200,
 		Repeat:     1,
 		Alpha:      128,
@@ -19,3 +22,80 @@ func TestNew(t *testing.T) {
 		t.Errorf("Got %+v;\n want: %+v", c, expected)
 	}
 }
+
+func TestConfig_ShouldStop(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		similarities []float64
+		elapsed      time.Duration
+		expected     bool
+	}{
+		{
+			name:         "No stop conditions",
+			config:       Config{},
+			similarities: []float64{50, 60, 70},
+			elapsed:      time.Hour,
+			expected:     false,
+		},
+		{
+			name:         "No steps were made",
+			config:       Config{TargetScore: 1},
+			similarities: []float64{},
+			expected:     false,
+		},
+		{
+			name:         "Time limit is exceeded",
+			config:       Config{TimeLimit: time.Minute},
+			similarities: []float64{50},
+			elapsed:      time.Minute,
+			expected:     true,
+		},
+		{
+			name:         "Time limit isn't exceeded",
+			config:       Config{TimeLimit: time.Minute},
+			similarities: []float64{50},
+			elapsed:      time.Second,
+			expected:     false,
+		},
+		{
+			name:         "Target score is reached",
+			config:       Config{TargetScore: 95},
+			similarities: []float64{90, 95.5},
+			expected:     true,
+		},
+		{
+			name:         "Target score isn't reached",
+			config:       Config{TargetScore: 95},
+			similarities: []float64{90, 94.9},
+			expected:     false,
+		},
+		{
+			name:         "Not enough steps to detect plateau",
+			config:       Config{MinImprovement: 0.1},
+			similarities: []float64{90, 90, 90},
+			expected:     false,
+		},
+		{
+			name:         "Image stopped improving",
+			config:       Config{MinImprovement: 0.1},
+			similarities: []float64{90, 90, 90, 90, 90, 90, 90, 90, 90, 90, 90.5},
+			expected:     true,
+		},
+		{
+			name:         "Image is improving",
+			config:       Config{MinImprovement: 0.1},
+			similarities: []float64{80, 81, 82, 83, 84, 85, 86, 87, 88, 89, 90},
+			expected:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.config.shouldStop(tt.similarities, tt.elapsed)
+			if res != tt.expected {
+				t.Errorf("got %v; want %v", res, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package primitive
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// maxQuantizeSamples limits the number of pixels that are used
+// to build the palette.
+const maxQuantizeSamples = 1 << 16
+
+// colorBox is a set of colors used by the median cut algorithm.
+type colorBox []color.RGBA
+
+// quantize builds the palette of at most n colors for the image
+// with the median cut algorithm.
+func quantize(img image.Image, n int) color.Palette {
+	b := img.Bounds()
+	step := 1
+	for (b.Dx()/step)*(b.Dy()/step) > maxQuantizeSamples {
+		step++
+	}
+
+	var samples colorBox
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			c.A = 255
+			samples = append(samples, c)
+		}
+	}
+	if len(samples) == 0 {
+		return color.Palette{color.Black}
+	}
+
+	boxes := []colorBox{samples}
+	for len(boxes) < n {
+		// split the box with the widest range of colors
+		i, ch, width := 0, 0, 0
+		for j, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if c, w := box.widest(); w > width {
+				i, ch, width = j, c, w
+			}
+		}
+		if width == 0 {
+			break
+		}
+
+		box := boxes[i]
+		sort.Slice(box, func(a, b int) bool {
+			return channel(box[a], ch) < channel(box[b], ch)
+		})
+		mid := splitIndex(box, ch)
+		boxes[i] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	p := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		p[i] = box.average()
+	}
+	return p
+}
+
+// widest returns the index of the channel with the widest range
+// of values in the box and the width of this range.
+func (b colorBox) widest() (int, int) {
+	lo := [3]uint8{255, 255, 255}
+	hi := [3]uint8{}
+	for _, c := range b {
+		for ch := 0; ch < 3; ch++ {
+			v := channel(c, ch)
+			if v < lo[ch] {
+				lo[ch] = v
+			}
+			if v > hi[ch] {
+				hi[ch] = v
+			}
+		}
+	}
+
+	idx, width := 0, 0
+	for ch := 0; ch < 3; ch++ {
+		if w := int(hi[ch]) - int(lo[ch]); w > width {
+			idx, width = ch, w
+		}
+	}
+	return idx, width
+}
+
+// splitIndex returns the index closest to the median of the box sorted by
+// the channel at which the value of the channel changes. This way the
+// same colors always end up in the same box.
+func splitIndex(b colorBox, ch int) int {
+	mid := len(b) / 2
+	for offset := 0; offset < len(b); offset++ {
+		for _, i := range []int{mid + offset, mid - offset} {
+			if i > 0 && i < len(b) && channel(b[i-1], ch) != channel(b[i], ch) {
+				return i
+			}
+		}
+	}
+	return mid
+}
+
+// average returns the mean color of the box.
+func (b colorBox) average() color.RGBA {
+	var r, g, bl int
+	for _, c := range b {
+		r += int(c.R)
+		g += int(c.G)
+		bl += int(c.B)
+	}
+	n := len(b)
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 255}
+}
+
+func channel(c color.RGBA, ch int) uint8 {
+	switch ch {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	default:
+		return c.B
+	}
+}
+
+// paletteMap maps colors to the indexes of the nearest colors
+// in the palette. Colors are cached with 5 bits per channel.
+type paletteMap struct {
+	palette color.Palette
+	cache   [1 << 15]int16
+}
+
+func newPaletteMap(p color.Palette) *paletteMap {
+	m := &paletteMap{palette: p}
+	for i := range m.cache {
+		m.cache[i] = -1
+	}
+	return m
+}
+
+// convert returns paletted copy of the image.
+func (m *paletteMap) convert(img image.Image) *image.Paletted {
+	b := img.Bounds()
+	dst := image.NewPaletted(b, m.palette)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			dst.SetColorIndex(x, y, m.index(c))
+		}
+	}
+	return dst
+}
+
+func (m *paletteMap) index(c color.RGBA) uint8 {
+	key := int(c.R>>3)<<10 | int(c.G>>3)<<5 | int(c.B>>3)
+	if i := m.cache[key]; i >= 0 {
+		return uint8(i)
+	}
+
+	i := m.palette.Index(color.RGBA{R: c.R, G: c.G, B: c.B, A: 255})
+	m.cache[key] = int16(i)
+	return uint8(i)
+}
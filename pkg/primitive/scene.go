@@ -0,0 +1,384 @@
+package primitive
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/fogleman/primitive/primitive"
+)
+
+// SceneVersion is the version of the Scene JSON schema.
+// It must be incremented on every incompatible change.
+const SceneVersion = 1
+
+// Scene is the exported representation of the created image.
+// Steps is the number of steps performed to create the image.
+//
+// Coordinates of the shapes are specified in the space of the downscaled
+// input image. To get coordinates on the canvas of the size Width x Height
+// they need to be translated by Translate and then multiplied by Scale
+// (as in the SVG output).
+type Scene struct {
+	Version    int          `json:"version"`
+	Steps      int          `json:"steps"`
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	Scale      float64      `json:"scale"`
+	Translate  float64      `json:"translate"`
+	Background string       `json:"background"`
+	Shapes     []SceneShape `json:"shapes"`
+}
+
+// SceneShape is one shape of the Scene in the order it was drawn.
+// The type of the Geometry depends on the Type of the shape.
+type SceneShape struct {
+	Type     string      `json:"type"`
+	Color    string      `json:"color"`
+	Alpha    int         `json:"alpha"`
+	Geometry interface{} `json:"geometry"`
+}
+
+// Types of the shapes in the Scene.
+const (
+	SceneTriangle         = "triangle"
+	SceneRectangle        = "rectangle"
+	SceneRotatedRectangle = "rotated_rectangle"
+	SceneEllipse          = "ellipse"
+	SceneCircle           = "circle"
+	SceneRotatedEllipse   = "rotated_ellipse"
+	ScenePolygon          = "polygon"
+	SceneBezier           = "bezier"
+)
+
+// Point is a point on the plane.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// PolygonGeometry describes triangles and polygons.
+type PolygonGeometry struct {
+	Points []Point `json:"points"`
+}
+
+// RectangleGeometry describes rectangles. X and Y are
+// coordinates of the top left corner.
+type RectangleGeometry struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// RotatedRectangleGeometry describes rotated rectangles. X and Y are
+// coordinates of the center. Angle is specified in degrees.
+type RotatedRectangleGeometry struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Angle  float64 `json:"angle"`
+}
+
+// EllipseGeometry describes ellipses and circles. X and Y are coordinates
+// of the center. Angle is specified in degrees.
+type EllipseGeometry struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Rx    float64 `json:"rx"`
+	Ry    float64 `json:"ry"`
+	Angle float64 `json:"angle"`
+}
+
+// BezierGeometry describes quadratic bezier curves. Control is
+// the control point of the curve. Width is the width of the stroke.
+type BezierGeometry struct {
+	Start   Point   `json:"start"`
+	Control Point   `json:"control"`
+	End     Point   `json:"end"`
+	Width   float64 `json:"width"`
+}
+
+// newScene exports shapes of the model.
+func newScene(model *primitive.Model) (Scene, error) {
+	bg := model.Background
+	scene := Scene{
+		Version:    SceneVersion,
+		Width:      model.Sw,
+		Height:     model.Sh,
+		Scale:      model.Scale,
+		Translate:  0.5,
+		Background: fmt.Sprintf("#%02x%02x%02x", bg.R, bg.G, bg.B),
+		Shapes:     make([]SceneShape, len(model.Shapes)),
+	}
+
+	for i, shape := range model.Shapes {
+		c := model.Colors[i]
+		s := SceneShape{
+			Color: fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B),
+			Alpha: c.A,
+		}
+
+		switch v := shape.(type) {
+		case *primitive.Triangle:
+			s.Type = SceneTriangle
+			s.Geometry = PolygonGeometry{Points: []Point{
+				{float64(v.X1), float64(v.Y1)},
+				{float64(v.X2), float64(v.Y2)},
+				{float64(v.X3), float64(v.Y3)},
+			}}
+		case *primitive.Rectangle:
+			x1, x2 := minMax(v.X1, v.X2)
+			y1, y2 := minMax(v.Y1, v.Y2)
+			s.Type = SceneRectangle
+			s.Geometry = RectangleGeometry{
+				X:      float64(x1),
+				Y:      float64(y1),
+				Width:  float64(x2 - x1 + 1),
+				Height: float64(y2 - y1 + 1),
+			}
+		case *primitive.RotatedRectangle:
+			s.Type = SceneRotatedRectangle
+			s.Geometry = RotatedRectangleGeometry{
+				X:      float64(v.X),
+				Y:      float64(v.Y),
+				Width:  float64(v.Sx),
+				Height: float64(v.Sy),
+				Angle:  float64(v.Angle),
+			}
+		case *primitive.Ellipse:
+			s.Type = SceneEllipse
+			if v.Circle {
+				s.Type = SceneCircle
+			}
+			s.Geometry = EllipseGeometry{
+				X:  float64(v.X),
+				Y:  float64(v.Y),
+				Rx: float64(v.Rx),
+				Ry: float64(v.Ry),
+			}
+		case *primitive.RotatedEllipse:
+			s.Type = SceneRotatedEllipse
+			s.Geometry = EllipseGeometry{X: v.X, Y: v.Y, Rx: v.Rx, Ry: v.Ry, Angle: v.Angle}
+		case *primitive.Polygon:
+			points := make([]Point, v.Order)
+			for j := range points {
+				points[j] = Point{v.X[j], v.Y[j]}
+			}
+			s.Type = ScenePolygon
+			s.Geometry = PolygonGeometry{Points: points}
+		case *primitive.Quadratic:
+			s.Type = SceneBezier
+			s.Geometry = BezierGeometry{
+				Start:   Point{v.X1, v.Y1},
+				Control: Point{v.X2, v.Y2},
+				End:     Point{v.X3, v.Y3},
+				Width:   v.Width,
+			}
+		default:
+			return Scene{}, fmt.Errorf("unknown shape type %T", shape)
+		}
+
+		scene.Shapes[i] = s
+	}
+
+	return scene, nil
+}
+
+// model reconstructs the model from the scene. The larger side
+// of the canvas of the model will be equal to size.
+func (s Scene) model(size int) (*primitive.Model, error) {
+	if s.Version != SceneVersion {
+		return nil, fmt.Errorf("unsupported scene version %d", s.Version)
+	}
+	if s.Width <= 0 || s.Height <= 0 {
+		return nil, fmt.Errorf("incorrect canvas size %dx%d", s.Width, s.Height)
+	}
+
+	factor := float64(size) / math.Max(float64(s.Width), float64(s.Height))
+	model := &primitive.Model{
+		Sw:         int(math.Max(1, math.Round(float64(s.Width)*factor))),
+		Sh:         int(math.Max(1, math.Round(float64(s.Height)*factor))),
+		Scale:      s.Scale * factor,
+		Background: primitive.MakeHexColor(s.Background),
+		Shapes:     make([]primitive.Shape, len(s.Shapes)),
+		Colors:     make([]primitive.Color, len(s.Shapes)),
+	}
+	model.Context = newContext(model, 1)
+
+	for i, ss := range s.Shapes {
+		shape, err := ss.shape(nil)
+		if err != nil {
+			return nil, fmt.Errorf("shape %d: %w", i, err)
+		}
+
+		c := primitive.MakeHexColor(ss.Color)
+		c.A = ss.Alpha
+		model.Shapes[i] = shape
+		model.Colors[i] = c
+
+		model.Context.SetRGBA255(c.R, c.G, c.B, c.A)
+		shape.Draw(model.Context, model.Scale)
+		model.Context.Fill()
+	}
+
+	return model, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface. It decodes
+// the geometry of the shape to the type that corresponds to the shape type.
+func (s *SceneShape) UnmarshalJSON(data []byte) error {
+	type sceneShape SceneShape
+	var raw struct {
+		sceneShape
+		Geometry json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = SceneShape(raw.sceneShape)
+	switch s.Type {
+	case SceneTriangle, ScenePolygon:
+		var g PolygonGeometry
+		if err := json.Unmarshal(raw.Geometry, &g); err != nil {
+			return err
+		}
+		s.Geometry = g
+	case SceneRectangle:
+		var g RectangleGeometry
+		if err := json.Unmarshal(raw.Geometry, &g); err != nil {
+			return err
+		}
+		s.Geometry = g
+	case SceneRotatedRectangle:
+		var g RotatedRectangleGeometry
+		if err := json.Unmarshal(raw.Geometry, &g); err != nil {
+			return err
+		}
+		s.Geometry = g
+	case SceneEllipse, SceneCircle, SceneRotatedEllipse:
+		var g EllipseGeometry
+		if err := json.Unmarshal(raw.Geometry, &g); err != nil {
+			return err
+		}
+		s.Geometry = g
+	case SceneBezier:
+		var g BezierGeometry
+		if err := json.Unmarshal(raw.Geometry, &g); err != nil {
+			return err
+		}
+		s.Geometry = g
+	default:
+		return fmt.Errorf("unknown shape type %q", s.Type)
+	}
+
+	return nil
+}
+
+// shape converts the scene shape back to the shape of the primitive package.
+// If worker is nil, the returned shape can only be drawn.
+func (s SceneShape) shape(worker *primitive.Worker) (primitive.Shape, error) {
+	switch g := s.Geometry.(type) {
+	case PolygonGeometry:
+		if s.Type == SceneTriangle {
+			if len(g.Points) != 3 {
+				return nil, fmt.Errorf("triangle has %d points", len(g.Points))
+			}
+			return &primitive.Triangle{
+				X1: round(g.Points[0].X), Y1: round(g.Points[0].Y),
+				X2: round(g.Points[1].X), Y2: round(g.Points[1].Y),
+				X3: round(g.Points[2].X), Y3: round(g.Points[2].Y),
+				Worker: worker,
+			}, nil
+		}
+
+		p := &primitive.Polygon{
+			Worker: worker,
+			Order:  len(g.Points),
+			X:      make([]float64, len(g.Points)),
+			Y:      make([]float64, len(g.Points)),
+		}
+		for i, point := range g.Points {
+			p.X[i], p.Y[i] = point.X, point.Y
+		}
+		return p, nil
+	case RectangleGeometry:
+		return &primitive.Rectangle{
+			Worker: worker,
+			X1:     round(g.X),
+			Y1:     round(g.Y),
+			X2:     round(g.X + g.Width - 1),
+			Y2:     round(g.Y + g.Height - 1),
+		}, nil
+	case RotatedRectangleGeometry:
+		return &primitive.RotatedRectangle{
+			Worker: worker,
+			X:      round(g.X),
+			Y:      round(g.Y),
+			Sx:     round(g.Width),
+			Sy:     round(g.Height),
+			Angle:  round(g.Angle),
+		}, nil
+	case EllipseGeometry:
+		if s.Type == SceneRotatedEllipse {
+			return &primitive.RotatedEllipse{Worker: worker, X: g.X, Y: g.Y, Rx: g.Rx, Ry: g.Ry, Angle: g.Angle}, nil
+		}
+		return &primitive.Ellipse{
+			Worker: worker,
+			X:      round(g.X),
+			Y:      round(g.Y),
+			Rx:     round(g.Rx),
+			Ry:     round(g.Ry),
+			Circle: s.Type == SceneCircle,
+		}, nil
+	case BezierGeometry:
+		return &primitive.Quadratic{
+			X1: g.Start.X, Y1: g.Start.Y,
+			X2: g.Control.X, Y2: g.Control.Y,
+			X3: g.End.X, Y3: g.End.Y,
+			Width:  g.Width,
+			Worker: worker,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown geometry %T of the shape %q", s.Geometry, s.Type)
+}
+
+// LoadScene reads the scene from the JSON file at path.
+func LoadScene(path string) (Scene, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return Scene{}, err
+	}
+
+	var scene Scene
+	if err := json.Unmarshal(data, &scene); err != nil {
+		return Scene{}, err
+	}
+	return scene, nil
+}
+
+// SaveScene writes the scene as JSON to the file at path.
+func SaveScene(path string, scene Scene) error {
+	data, err := json.Marshal(scene)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Clean(path), data, 0600)
+}
+
+func round(x float64) int {
+	return int(math.Round(x))
+}
+
+func minMax(a, b int) (int, int) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
@@ -0,0 +1,227 @@
+package primitive
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fogleman/primitive/primitive"
+)
+
+func TestNewScene(t *testing.T) {
+	model := newTestModel(t, 0)
+	w := model.Workers[0]
+	shapes := []primitive.Shape{
+		&primitive.Triangle{Worker: w, X1: 1, Y1: 2, X2: 10, Y2: 3, X3: 5, Y3: 12},
+		&primitive.Rectangle{Worker: w, X1: 10, Y1: 12, X2: 2, Y2: 4},
+		&primitive.RotatedRectangle{Worker: w, X: 8, Y: 8, Sx: 4, Sy: 6, Angle: 30},
+		&primitive.Ellipse{Worker: w, X: 5, Y: 6, Rx: 3, Ry: 2},
+		&primitive.Ellipse{Worker: w, X: 5, Y: 6, Rx: 3, Ry: 3, Circle: true},
+		&primitive.RotatedEllipse{Worker: w, X: 5.5, Y: 6.5, Rx: 3, Ry: 2, Angle: 45},
+		&primitive.Polygon{Worker: w, Order: 4, X: []float64{1, 9, 9, 1}, Y: []float64{1, 1, 9, 9}},
+		&primitive.Quadratic{Worker: w, X1: 1, Y1: 1, X2: 5, Y2: 10, X3: 12, Y3: 2, Width: 0.5},
+	}
+	for _, s := range shapes {
+		model.Add(s, 128)
+	}
+
+	expected := []SceneShape{
+		{Type: SceneTriangle, Geometry: PolygonGeometry{Points: []Point{{1, 2}, {10, 3}, {5, 12}}}},
+		{Type: SceneRectangle, Geometry: RectangleGeometry{X: 2, Y: 4, Width: 9, Height: 9}},
+		{Type: SceneRotatedRectangle, Geometry: RotatedRectangleGeometry{X: 8, Y: 8, Width: 4, Height: 6, Angle: 30}},
+		{Type: SceneEllipse, Geometry: EllipseGeometry{X: 5, Y: 6, Rx: 3, Ry: 2}},
+		{Type: SceneCircle, Geometry: EllipseGeometry{X: 5, Y: 6, Rx: 3, Ry: 3}},
+		{Type: SceneRotatedEllipse, Geometry: EllipseGeometry{X: 5.5, Y: 6.5, Rx: 3, Ry: 2, Angle: 45}},
+		{Type: ScenePolygon, Geometry: PolygonGeometry{Points: []Point{{1, 1}, {9, 1}, {9, 9}, {1, 9}}}},
+		{Type: SceneBezier, Geometry: BezierGeometry{Start: Point{1, 1}, Control: Point{5, 10}, End: Point{12, 2}, Width: 0.5}},
+	}
+
+	scene, err := newScene(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scene.Version != SceneVersion {
+		t.Errorf("got version %d; want %d", scene.Version, SceneVersion)
+	}
+	if scene.Width != model.Sw || scene.Height != model.Sh {
+		t.Errorf("got canvas %dx%d; want %dx%d", scene.Width, scene.Height, model.Sw, model.Sh)
+	}
+	if len(scene.Shapes) != len(expected) {
+		t.Fatalf("got %d shapes; want %d", len(scene.Shapes), len(expected))
+	}
+	for i, s := range scene.Shapes {
+		if s.Type != expected[i].Type {
+			t.Errorf("shape %d: got type %s; want %s", i, s.Type, expected[i].Type)
+		}
+		if !reflect.DeepEqual(s.Geometry, expected[i].Geometry) {
+			t.Errorf("shape %d: got geometry %+v; want %+v", i, s.Geometry, expected[i].Geometry)
+		}
+		if s.Alpha != 128 {
+			t.Errorf("shape %d: got alpha %d; want %d", i, s.Alpha, 128)
+		}
+	}
+
+	if _, err := json.Marshal(scene); err != nil {
+		t.Errorf("couldn't marshal scene: %v", err)
+	}
+}
+
+func TestSceneRoundTrip(t *testing.T) {
+	model := newTestModel(t, 0)
+	for _, shapeType := range []primitive.ShapeType{
+		primitive.ShapeTypeTriangle, primitive.ShapeTypeRectangle, primitive.ShapeTypeEllipse,
+		primitive.ShapeTypeCircle, primitive.ShapeTypeRotatedRectangle, primitive.ShapeTypeQuadratic,
+		primitive.ShapeTypeRotatedEllipse, primitive.ShapeTypePolygon,
+	} {
+		model.Step(shapeType, 128, 0)
+	}
+
+	scene, err := newScene(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "scene.json")
+	if err := SaveScene(path, scene); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadScene(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := loaded.model(scene.Width)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := newScene(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(res, scene) {
+		t.Errorf("got scene %+v;\n want %+v", res, scene)
+	}
+}
+
+func TestConfig_Render(t *testing.T) {
+	model := newTestModel(t, 3)
+	scene, err := newScene(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(1)
+	c.OutputSize = 128
+	c.Extension = "png"
+	path := filepath.Join(t.TempDir(), "out.png")
+	if err := c.Render(scene, path); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(128, 64) {
+		t.Errorf("got image size %v; want %v", size, image.Pt(128, 64))
+	}
+}
+
+func TestLoadSceneWhenShapeTypeIsUnknown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene.json")
+	data := `{"version":1,"width":1,"height":1,"shapes":[{"type":"star","geometry":{}}]}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadScene(path); err == nil {
+		t.Error("expected error for the unknown shape type")
+	}
+}
+
+func TestConfig_Continue(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "in.png")
+	if err := primitive.SavePNG(inputPath, newTestModel(t, 0).Target); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(1)
+	c.OutputSize = 64
+	c.Iterations = 3
+	c.Shapes = NewShapeSet(ShapeTriangle)
+	c.Repeat = 0
+	c.Extension = "json"
+	scene, err := c.Create(inputPath, filepath.Join(dir, "out1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Iterations = 2
+	res, err := c.Continue(inputPath, scene, filepath.Join(dir, "out2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Steps != 5 {
+		t.Errorf("got %d steps; want %d", res.Steps, 5)
+	}
+	// the steps are written to the file, so the result can be continued again
+	loaded, err := LoadScene(filepath.Join(dir, "out2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Steps != 5 {
+		t.Errorf("got %d steps in the file; want %d", loaded.Steps, 5)
+	}
+	if len(res.Shapes) != 5 {
+		t.Fatalf("got %d shapes; want %d", len(res.Shapes), 5)
+	}
+	if !reflect.DeepEqual(res.Shapes[:3], scene.Shapes) {
+		t.Errorf("got shapes %+v;\n want %+v", res.Shapes[:3], scene.Shapes)
+	}
+}
+
+func TestConfig_CreateWithCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "in.png")
+	if err := primitive.SavePNG(inputPath, newTestModel(t, 0).Target); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(1)
+	c.OutputSize = 64
+	c.Iterations = 3
+	c.Repeat = 0
+	c.Extension = "json"
+
+	var checkpoints []Scene
+	_, err := c.Create(inputPath, filepath.Join(dir, "out.json"), Checkpoint{
+		Save: func(scene Scene) { checkpoints = append(checkpoints, scene) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with zero interval the checkpoint is saved after each step
+	if len(checkpoints) != c.Iterations {
+		t.Fatalf("got %d checkpoints; want %d", len(checkpoints), c.Iterations)
+	}
+	for i, scene := range checkpoints {
+		if scene.Steps != i+1 || len(scene.Shapes) != i+1 {
+			t.Errorf("checkpoint %d: got %d steps and %d shapes; want %d", i, scene.Steps, len(scene.Shapes), i+1)
+		}
+	}
+}
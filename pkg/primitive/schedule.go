@@ -0,0 +1,164 @@
+package primitive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScheduleStage overrides parameters of the config on the steps from
+// From to To inclusive. Steps are numbered starting with 1, zero To means
+// that the stage lasts till the last step. Negative Alpha and Repeat as
+// well as zero Shapes mean that the parameter isn't overridden.
+type ScheduleStage struct {
+	From   int
+	To     int
+	Alpha  int
+	Repeat int
+	Shapes ShapeSet
+}
+
+// Schedule is a list of the stages that don't overlap.
+//
+// The compact syntax of the schedule is the list of the stages separated
+// by ';'. Each stage is a range of the steps and parameters separated by
+// ':', parameters are separated by ','. For example:
+//
+//	1-100:a=255,s=1;101-500:a=64,s=3+4,r=2;501-:a=32
+//
+// Parameters are 'a' (alpha), 'r' (repeat) and 's' (shapes in the format
+// of ShapeSet.String).
+type Schedule []ScheduleStage
+
+// ParseSchedule parses the schedule in the compact syntax.
+// Whitespace is ignored.
+func ParseSchedule(str string) (Schedule, error) {
+	str = strings.Join(strings.Fields(str), "")
+	if str == "" {
+		return nil, nil
+	}
+
+	var schedule Schedule
+	for _, s := range strings.Split(strings.TrimSuffix(str, ";"), ";") {
+		stage, err := parseScheduleStage(s)
+		if err != nil {
+			return nil, fmt.Errorf("stage %q: %w", s, err)
+		}
+
+		for _, other := range schedule {
+			if stage.overlaps(other) {
+				return nil, fmt.Errorf("stage %q overlaps with the previous stages", s)
+			}
+		}
+		schedule = append(schedule, stage)
+	}
+
+	return schedule, nil
+}
+
+func parseScheduleStage(s string) (ScheduleStage, error) {
+	stage := ScheduleStage{Alpha: -1, Repeat: -1}
+
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return ScheduleStage{}, fmt.Errorf("parameters aren't specified")
+	}
+	steps, params := s[:i], s[i+1:]
+
+	// steps
+	from, to := steps, steps
+	if j := strings.Index(steps, "-"); j >= 0 {
+		from, to = steps[:j], steps[j+1:]
+	}
+	var err error
+	if stage.From, err = strconv.Atoi(from); err != nil || stage.From < 1 {
+		return ScheduleStage{}, fmt.Errorf("incorrect first step %q", from)
+	}
+	if to != "" {
+		if stage.To, err = strconv.Atoi(to); err != nil || stage.To < stage.From {
+			return ScheduleStage{}, fmt.Errorf("incorrect last step %q", to)
+		}
+	}
+
+	// parameters
+	for _, p := range strings.Split(params, ",") {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return ScheduleStage{}, fmt.Errorf("incorrect parameter %q", p)
+		}
+
+		switch kv[0] {
+		case "a":
+			if stage.Alpha, err = strconv.Atoi(kv[1]); err != nil || stage.Alpha < 0 || stage.Alpha > 255 {
+				return ScheduleStage{}, fmt.Errorf("incorrect alpha %q", kv[1])
+			}
+		case "r":
+			stage.Repeat, err = strconv.Atoi(kv[1])
+			if err != nil || stage.Repeat < MinRepeat || stage.Repeat > MaxRepeat {
+				return ScheduleStage{}, fmt.Errorf("incorrect repeat %q", kv[1])
+			}
+		case "s":
+			if stage.Shapes, err = ParseShapeSet(kv[1]); err != nil {
+				return ScheduleStage{}, err
+			}
+		default:
+			return ScheduleStage{}, fmt.Errorf("unknown parameter %q", kv[0])
+		}
+	}
+
+	return stage, nil
+}
+
+func (s ScheduleStage) contains(step int) bool {
+	return step >= s.From && (s.To == 0 || step <= s.To)
+}
+
+func (s ScheduleStage) overlaps(other ScheduleStage) bool {
+	return (s.To == 0 || s.To >= other.From) && (other.To == 0 || other.To >= s.From)
+}
+
+// String returns the schedule in the compact syntax.
+func (s Schedule) String() string {
+	stages := make([]string, len(s))
+	for i, stage := range s {
+		steps := fmt.Sprintf("%d-", stage.From)
+		if stage.To != 0 {
+			steps += strconv.Itoa(stage.To)
+		}
+
+		var params []string
+		if stage.Alpha >= 0 {
+			params = append(params, fmt.Sprintf("a=%d", stage.Alpha))
+		}
+		if stage.Repeat >= 0 {
+			params = append(params, fmt.Sprintf("r=%d", stage.Repeat))
+		}
+		if stage.Shapes != 0 {
+			params = append(params, fmt.Sprintf("s=%s", stage.Shapes))
+		}
+		stages[i] = fmt.Sprintf("%s:%s", steps, strings.Join(params, ","))
+	}
+	return strings.Join(stages, ";")
+}
+
+// apply returns the config with parameters overridden
+// by the stage that contains the step.
+func (s Schedule) apply(c Config, step int) Config {
+	for _, stage := range s {
+		if !stage.contains(step) {
+			continue
+		}
+
+		if stage.Alpha >= 0 {
+			c.Alpha = stage.Alpha
+		}
+		if stage.Repeat >= 0 {
+			c.Repeat = stage.Repeat
+		}
+		if stage.Shapes != 0 {
+			c.Shapes = stage.Shapes
+		}
+		break
+	}
+	return c
+}
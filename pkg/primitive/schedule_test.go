@@ -0,0 +1,133 @@
+package primitive
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fogleman/primitive/primitive"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		str      string
+		expected Schedule
+		canon    string
+	}{
+		{
+			name:     "Empty",
+			str:      " ",
+			expected: nil,
+			canon:    "",
+		},
+		{
+			name: "Several stages",
+			str:  "1-100: a=255, s=1; 101-500:a=64,s=3+4,r=2; 501-:a=0;",
+			expected: Schedule{
+				{From: 1, To: 100, Alpha: 255, Repeat: -1, Shapes: NewShapeSet(ShapeTriangle)},
+				{From: 101, To: 500, Alpha: 64, Repeat: 2, Shapes: NewShapeSet(ShapeEllipse, ShapeCircle)},
+				{From: 501, Alpha: 0, Repeat: -1},
+			},
+			canon: "1-100:a=255,s=1;101-500:a=64,r=2,s=3+4;501-:a=0",
+		},
+		{
+			name:     "Single step",
+			str:      "5:r=6",
+			expected: Schedule{{From: 5, To: 5, Alpha: -1, Repeat: 6}},
+			canon:    "5-5:r=6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(schedule, tt.expected) {
+				t.Errorf("got schedule %+v; want %+v", schedule, tt.expected)
+			}
+			if str := schedule.String(); str != tt.canon {
+				t.Errorf("got string %q; want %q", str, tt.canon)
+			}
+		})
+	}
+}
+
+func TestParseScheduleWhenIncorrect(t *testing.T) {
+	tests := []string{
+		"1-100",
+		"0-100:a=1",
+		"100-1:a=1",
+		"1-100:a=256",
+		"1-100:r=-1",
+		"1-100:r=0",
+		"1-:r=7",
+		"1-:r=100000",
+		"1-100:s=9",
+		"1-100:x=1",
+		"1-100:a",
+		"1-100:a=1;50-150:a=2",
+		"100-:a=1;200-300:a=2",
+	}
+
+	for _, str := range tests {
+		if _, err := ParseSchedule(str); err == nil {
+			t.Errorf("expected error for %q", str)
+		}
+	}
+}
+
+func TestSchedule_Apply(t *testing.T) {
+	schedule, err := ParseSchedule("1-10:a=255,s=1;11-:r=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(1)
+
+	tests := []struct {
+		step     int
+		expected Config
+	}{
+		{1, func() Config { c := c; c.Alpha = 255; c.Shapes = NewShapeSet(ShapeTriangle); return c }()},
+		{10, func() Config { c := c; c.Alpha = 255; c.Shapes = NewShapeSet(ShapeTriangle); return c }()},
+		{11, func() Config { c := c; c.Repeat = 3; return c }()},
+		{1000, func() Config { c := c; c.Repeat = 3; return c }()},
+	}
+
+	for _, tt := range tests {
+		if res := schedule.apply(c, tt.step); res != tt.expected {
+			t.Errorf("step %d: got config %+v; want %+v", tt.step, res, tt.expected)
+		}
+	}
+}
+
+func TestConfig_CreateWithSchedule(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "in.png")
+	if err := primitive.SavePNG(inputPath, newTestModel(t, 0).Target); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(1)
+	c.OutputSize = 64
+	c.Iterations = 4
+	c.Repeat = 0
+	c.Extension = "json"
+	c.Schedule = "1-2:s=2;3-:s=1"
+	scene, err := c.Create(inputPath, filepath.Join(dir, "out.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{SceneRectangle, SceneRectangle, SceneTriangle, SceneTriangle}
+	if len(scene.Shapes) != len(expected) {
+		t.Fatalf("got %d shapes; want %d", len(scene.Shapes), len(expected))
+	}
+	for i, s := range scene.Shapes {
+		if s.Type != expected[i] {
+			t.Errorf("shape %d: got type %s; want %s", i, s.Type, expected[i])
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package primitive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ShapeSet is a set of the shapes that can be used to create images.
+// The set with ShapeAny as well as the empty set means that all shapes
+// can be used.
+type ShapeSet uint16
+
+// allShapes is the set with all shapes except ShapeAny.
+const allShapes ShapeSet = 1<<(ShapePolygon+1) - 2
+
+// NewShapeSet returns the set of the shapes.
+func NewShapeSet(shapes ...Shape) ShapeSet {
+	var s ShapeSet
+	for _, shape := range shapes {
+		s |= 1 << shape
+	}
+	return s.normalize()
+}
+
+// ParseShapeSet parses the set in the format returned by String.
+func ParseShapeSet(str string) (ShapeSet, error) {
+	var shapes []Shape
+	for _, part := range strings.Split(str, "+") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("incorrect shape %q", part)
+		}
+		if n < int(ShapeAny) || n > int(ShapePolygon) {
+			return 0, fmt.Errorf("unknown shape %d", n)
+		}
+		shapes = append(shapes, Shape(n))
+	}
+
+	return NewShapeSet(shapes...), nil
+}
+
+// normalize replaces the set with all shapes with ShapeAny.
+func (s ShapeSet) normalize() ShapeSet {
+	if s == 0 || s&(1<<ShapeAny) != 0 || s&allShapes == allShapes {
+		return 1 << ShapeAny
+	}
+	return s
+}
+
+// Has reports whether the shape is in the set. If all shapes can
+// be used, every shape (including ShapeAny) is in the set.
+func (s ShapeSet) Has(shape Shape) bool {
+	s = s.normalize()
+	if s == 1<<ShapeAny {
+		return true
+	}
+	return s&(1<<shape) != 0
+}
+
+// Toggle returns the set with the shape added if it isn't in the set or
+// removed otherwise. Toggling ShapeAny selects all shapes. The last shape
+// can't be removed from the set.
+func (s ShapeSet) Toggle(shape Shape) ShapeSet {
+	if shape == ShapeAny {
+		return 1 << ShapeAny
+	}
+
+	s = s.normalize()
+	if s == 1<<ShapeAny {
+		s = allShapes
+	}
+
+	toggled := s ^ 1<<shape
+	if toggled == 0 {
+		return s.normalize()
+	}
+	return toggled.normalize()
+}
+
+// Shapes returns the shapes of the set in ascending order. If all
+// shapes can be used, it returns only ShapeAny.
+func (s ShapeSet) Shapes() []Shape {
+	s = s.normalize()
+
+	var shapes []Shape
+	for shape := ShapeAny; shape <= ShapePolygon; shape++ {
+		if s&(1<<shape) != 0 {
+			shapes = append(shapes, shape)
+		}
+	}
+	return shapes
+}
+
+// String returns the shapes of the set joined with "+", for example "1+3".
+func (s ShapeSet) String() string {
+	shapes := s.Shapes()
+	parts := make([]string, len(shapes))
+	for i, shape := range shapes {
+		parts[i] = strconv.Itoa(int(shape))
+	}
+	return strings.Join(parts, "+")
+}
+
+// Scan implements fmt.Scanner interface, so the
+// set can be read from the log messages.
+func (s *ShapeSet) Scan(state fmt.ScanState, _ rune) error {
+	token, err := state.Token(true, func(r rune) bool {
+		return r >= '0' && r <= '9' || r == '+'
+	})
+	if err != nil {
+		return err
+	}
+
+	set, err := ParseShapeSet(string(token))
+	if err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
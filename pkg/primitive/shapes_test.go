@@ -0,0 +1,136 @@
+package primitive
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/fogleman/primitive/primitive"
+)
+
+func TestShapeSet_Toggle(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      ShapeSet
+		shape    Shape
+		expected []Shape
+	}{
+		{
+			name:     "Removes shape from all shapes",
+			set:      NewShapeSet(ShapeAny),
+			shape:    ShapeTriangle,
+			expected: []Shape{ShapeRectangle, ShapeEllipse, ShapeCircle, ShapeRotatedRectangle, ShapeBezier, ShapeRotatedEllipse, ShapePolygon},
+		},
+		{
+			name:     "Adds shape",
+			set:      NewShapeSet(ShapeTriangle),
+			shape:    ShapeEllipse,
+			expected: []Shape{ShapeTriangle, ShapeEllipse},
+		},
+		{
+			name:     "Removes shape",
+			set:      NewShapeSet(ShapeTriangle, ShapeEllipse),
+			shape:    ShapeTriangle,
+			expected: []Shape{ShapeEllipse},
+		},
+		{
+			name:     "Doesn't remove the last shape",
+			set:      NewShapeSet(ShapeEllipse),
+			shape:    ShapeEllipse,
+			expected: []Shape{ShapeEllipse},
+		},
+		{
+			name:     "Selects all shapes",
+			set:      NewShapeSet(ShapeEllipse),
+			shape:    ShapeAny,
+			expected: []Shape{ShapeAny},
+		},
+		{
+			name:     "Adds the last missing shape",
+			set:      NewShapeSet(ShapeAny).Toggle(ShapeBezier),
+			shape:    ShapeBezier,
+			expected: []Shape{ShapeAny},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := tt.set.Toggle(tt.shape).Shapes()
+			if !reflect.DeepEqual(shapes, tt.expected) {
+				t.Errorf("got shapes %v; want %v", shapes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShapeSet_Has(t *testing.T) {
+	all := NewShapeSet(ShapeAny)
+	if !all.Has(ShapeAny) || !all.Has(ShapeBezier) {
+		t.Error("set with all shapes doesn't contain some of them")
+	}
+	if !ShapeSet(0).Has(ShapeTriangle) {
+		t.Error("empty set doesn't mean all shapes")
+	}
+
+	set := NewShapeSet(ShapeTriangle, ShapeCircle)
+	if !set.Has(ShapeCircle) || set.Has(ShapeAny) || set.Has(ShapeEllipse) {
+		t.Errorf("got wrong shapes in the set %v", set)
+	}
+}
+
+func TestShapeSet_StringAndScan(t *testing.T) {
+	tests := []struct {
+		set ShapeSet
+		str string
+	}{
+		{NewShapeSet(ShapeAny), "0"},
+		{NewShapeSet(ShapeRectangle), "2"},
+		{NewShapeSet(ShapePolygon, ShapeTriangle, ShapeEllipse), "1+3+8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			if str := tt.set.String(); str != tt.str {
+				t.Errorf("got string %q; want %q", str, tt.str)
+			}
+
+			var set ShapeSet
+			var n int
+			_, err := fmt.Sscanf(fmt.Sprintf("shape=%v, alpha=%d", tt.set, 128), "shape=%v, alpha=%d", &set, &n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if set != tt.set || n != 128 {
+				t.Errorf("got set %v and alpha %d; want %v and %d", set, n, tt.set, 128)
+			}
+		})
+	}
+}
+
+func TestParseShapeSetWhenShapeIsIncorrect(t *testing.T) {
+	for _, str := range []string{"", "9", "1+", "a"} {
+		if _, err := ParseShapeSet(str); err == nil {
+			t.Errorf("expected error for %q", str)
+		}
+	}
+}
+
+func TestStep(t *testing.T) {
+	model := newTestModel(t, 0)
+	shapes := NewShapeSet(ShapeRectangle, ShapeEllipse)
+	for i := 0; i < 10; i++ {
+		step(model, shapes, 128, 0)
+	}
+
+	for i, shape := range model.Shapes {
+		switch v := shape.(type) {
+		case *primitive.Rectangle:
+		case *primitive.Ellipse:
+			if v.Circle {
+				t.Errorf("shape %d is a circle", i)
+			}
+		default:
+			t.Errorf("shape %d has type %T that isn't selected", i, shape)
+		}
+	}
+}
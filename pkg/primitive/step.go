@@ -0,0 +1,78 @@
+package primitive
+
+import (
+	"github.com/fogleman/primitive/primitive"
+)
+
+// step finds optimal shape among the shapes of the set and adds it
+// to the model. It works the same way as the primitive.Model.Step.
+func step(model *primitive.Model, shapes ShapeSet, alpha, repeat int) {
+	types := shapes.Shapes()
+	if len(types) == 1 {
+		model.Step(primitive.ShapeType(types[0]), alpha, repeat)
+		return
+	}
+
+	state := bestState(model, types, alpha, 1000, 100, 16)
+	model.Add(state.Shape, state.Alpha)
+
+	for i := 0; i < repeat; i++ {
+		state.Worker.Init(model.Current, model.Score)
+		a := state.Energy()
+		state = primitive.HillClimb(state, 100).(*primitive.State)
+		b := state.Energy()
+		if a == b {
+			break
+		}
+		model.Add(state.Shape, state.Alpha)
+	}
+}
+
+// bestState runs workers of the model in parallel. Each worker performs
+// m/len(model.Workers) hill climbs with the given age starting from the best
+// of n random states. The type of each random state is chosen among types.
+func bestState(model *primitive.Model, types []Shape, alpha, n, age, m int) *primitive.State {
+	wn := len(model.Workers)
+	wm := (m + wn - 1) / wn
+
+	ch := make(chan *primitive.State, wn)
+	for _, worker := range model.Workers {
+		worker.Init(model.Current, model.Score)
+		go func(worker *primitive.Worker) {
+			ch <- bestHillClimbState(worker, types, alpha, n, age, wm)
+		}(worker)
+	}
+
+	var best *primitive.State
+	for i := 0; i < wn; i++ {
+		state := <-ch
+		if best == nil || state.Energy() < best.Energy() {
+			best = state
+		}
+	}
+	return best
+}
+
+func bestHillClimbState(worker *primitive.Worker, types []Shape, alpha, n, age, m int) *primitive.State {
+	var best *primitive.State
+	for i := 0; i < m; i++ {
+		state := bestRandomState(worker, types, alpha, n)
+		state = primitive.HillClimb(state, age).(*primitive.State)
+		if best == nil || state.Energy() < best.Energy() {
+			best = state
+		}
+	}
+	return best
+}
+
+func bestRandomState(worker *primitive.Worker, types []Shape, alpha, n int) *primitive.State {
+	var best *primitive.State
+	for i := 0; i < n; i++ {
+		t := types[worker.Rnd.Intn(len(types))]
+		state := worker.RandomState(primitive.ShapeType(t), alpha)
+		if best == nil || state.Energy() < best.Energy() {
+			best = state
+		}
+	}
+	return best
+}
@@ -10,10 +10,13 @@ import (
 
 // Operation object contains information
 // needed to create primitive image.
+// If ResultID isn't empty, the operation continues
+// the result with this ID instead of starting over.
 type Operation struct {
-	UserID  int64
-	ImgPath string
-	Config  primitive.Config
+	UserID   int64
+	ImgPath  string
+	ResultID string
+	Config   primitive.Config
 }
 
 // Queue represents a linked list based queue.
@@ -103,3 +106,37 @@ func (q *Queue) GetNumOperations(userID int64) int {
 	}
 	return counter
 }
+
+// Operations returns all operations in the queue in their order.
+func (q *Queue) Operations() []Operation {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	operations := make([]Operation, 0, q.elements.Len())
+	for e := q.elements.Front(); e != nil; e = e.Next() {
+		operations = append(operations, e.Value.(Operation))
+	}
+	return operations
+}
+
+// Remove removes the first operation of the user with the input
+// and returns it. The continuations of the results have the same input,
+// so the earliest of them is removed. The first operation of the queue
+// is being created by the worker, so it isn't removed. If there isn't such an operation
+// then second return parameter will be equal to false.
+func (q *Queue) Remove(userID int64, imgPath string) (Operation, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	front := q.elements.Front()
+	if front == nil {
+		return Operation{}, false
+	}
+	for e := front.Next(); e != nil; e = e.Next() {
+		op := e.Value.(Operation)
+		if op.UserID == userID && op.ImgPath == imgPath {
+			return q.elements.Remove(e).(Operation), true
+		}
+	}
+	return Operation{}, false
+}
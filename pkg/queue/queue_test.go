@@ -205,3 +205,53 @@ func TestQueue_GetNumOperations(t *testing.T) {
 		})
 	}
 }
+
+func TestQueue_Remove(t *testing.T) {
+	q := New()
+	for _, path := range []string{"1.jpg", "2.jpg", "3.jpg"} {
+		q.Enqueue(Operation{UserID: 1, ImgPath: path})
+	}
+
+	op, ok := q.Remove(1, "2.jpg")
+	if !ok || op.ImgPath != "2.jpg" {
+		t.Errorf("Remove(1, 2.jpg) = %v, %t; want the second operation", op, ok)
+	}
+
+	tests := []struct {
+		userID  int64
+		imgPath string
+	}{
+		// the first operation is in progress
+		{1, "1.jpg"},
+		// the operation is already removed
+		{1, "2.jpg"},
+		{2, "3.jpg"},
+	}
+	for _, tt := range tests {
+		if _, ok := q.Remove(tt.userID, tt.imgPath); ok {
+			t.Errorf("Remove(%d, %s) returned true", tt.userID, tt.imgPath)
+		}
+	}
+
+	expected := []Operation{{UserID: 1, ImgPath: "1.jpg"}, {UserID: 1, ImgPath: "3.jpg"}}
+	if operations := q.Operations(); !reflect.DeepEqual(operations, expected) {
+		t.Errorf("Operations() = %v; want %v", operations, expected)
+	}
+}
+
+func TestQueue_RemoveWhenInputIsShared(t *testing.T) {
+	q := New()
+	q.Enqueue(Operation{UserID: 2, ImgPath: "2.jpg"})
+	q.Enqueue(Operation{UserID: 1, ImgPath: "1.jpg"})
+	q.Enqueue(Operation{UserID: 1, ImgPath: "1.jpg", ResultID: "1_1"})
+
+	op, ok := q.Remove(1, "1.jpg")
+	if !ok || op.ResultID != "" {
+		t.Errorf("Remove(1, 1.jpg) = %v, %t; want the earliest operation", op, ok)
+	}
+
+	expected := []Operation{{UserID: 2, ImgPath: "2.jpg"}, {UserID: 1, ImgPath: "1.jpg", ResultID: "1_1"}}
+	if operations := q.Operations(); !reflect.DeepEqual(operations, expected) {
+		t.Errorf("Operations() = %v; want %v", operations, expected)
+	}
+}
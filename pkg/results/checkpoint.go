@@ -0,0 +1,68 @@
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/internal/fileutil"
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+	"github.com/lazy-void/primitive-bot/pkg/queue"
+)
+
+// checkpointFile is the name of the file with the checkpoint. Operations are
+// processed one at a time, so there is at most one checkpoint at any time.
+const checkpointFile = "checkpoint.json"
+
+// Checkpoint contains the intermediate state of the operation that is in
+// progress. Base is the number of steps of the scene before the operation
+// was started, so Scene.Steps-Base steps of the operation are already done.
+// Elapsed is the time spent on the operation, so the resumed operation
+// doesn't exceed its time limit.
+type Checkpoint struct {
+	Operation queue.Operation `json:"operation"`
+	Base      int             `json:"base"`
+	Scene     primitive.Scene `json:"scene"`
+	Elapsed   time.Duration   `json:"elapsed"`
+	Created   time.Time       `json:"created"`
+}
+
+// SaveCheckpoint writes the checkpoint to the store
+// replacing the previous one.
+func (s *Store) SaveCheckpoint(c Checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFile(filepath.Join(s.dir, checkpointFile), data)
+}
+
+// GetCheckpoint returns the checkpoint. If there isn't one,
+// the returned error will be ErrNotFound.
+func (s *Store) GetCheckpoint() (Checkpoint, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return Checkpoint{}, ErrNotFound
+	}
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	c := Checkpoint{Operation: queue.Operation{Config: primitive.New(s.workers)}}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Checkpoint{}, err
+	}
+	return c, nil
+}
+
+// DeleteCheckpoint removes the checkpoint if it exists.
+func (s *Store) DeleteCheckpoint() error {
+	err := os.Remove(filepath.Join(s.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
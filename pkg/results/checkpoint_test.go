@@ -0,0 +1,67 @@
+package results
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+	"github.com/lazy-void/primitive-bot/pkg/queue"
+)
+
+func TestStore_Checkpoint(t *testing.T) {
+	workers := 2
+	s := NewStore(t.TempDir(), workers, 0, time.Hour, nil)
+
+	if _, err := s.GetCheckpoint(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v; want %v", err, ErrNotFound)
+	}
+
+	c := Checkpoint{
+		Operation: queue.Operation{
+			UserID:   123456789,
+			ImgPath:  "inputs/img.jpg",
+			ResultID: "123456789_1621774849",
+			Config:   primitive.New(workers),
+		},
+		Base: 200,
+		Scene: primitive.Scene{
+			Version:    primitive.SceneVersion,
+			Steps:      250,
+			Width:      10,
+			Height:     5,
+			Scale:      1,
+			Translate:  0.5,
+			Background: "#ffffff",
+			Shapes:     []primitive.SceneShape{},
+		},
+		Elapsed: 90 * time.Second,
+		Created: time.Unix(1621774849, 0).UTC(),
+	}
+	if err := s.SaveCheckpoint(c); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.GetCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, c) {
+		t.Errorf("got checkpoint %+v;\n want %+v", res, c)
+	}
+	// operations are compared to find out if the checkpoint belongs to them
+	if res.Operation != c.Operation {
+		t.Errorf("got operation %+v; want %+v", res.Operation, c.Operation)
+	}
+
+	if err := s.DeleteCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetCheckpoint(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v; want %v", err, ErrNotFound)
+	}
+	if err := s.DeleteCheckpoint(); err != nil {
+		t.Errorf("got error %v when deleting non-existing checkpoint", err)
+	}
+}
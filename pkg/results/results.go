@@ -0,0 +1,142 @@
+// Package results implements storage of the finished operations.
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/internal/fileutil"
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+// ErrNotFound is returned when the result doesn't exist.
+var ErrNotFound = errors.New("result not found")
+
+// Result contains information about the finished operation
+// that is needed to render it again.
+type Result struct {
+	ID      string           `json:"id"`
+	UserID  int64            `json:"user_id"`
+	ImgPath string           `json:"img_path"`
+	Config  primitive.Config `json:"config"`
+	Scene   primitive.Scene  `json:"scene"`
+	Created time.Time        `json:"created"`
+}
+
+// NewID returns ID of the result of the operation
+// of the user that was started at the specified time.
+func NewID(userID int64, start time.Time) string {
+	return fmt.Sprintf("%d_%d", userID, start.Unix())
+}
+
+// Store keeps results as JSON files in the directory.
+type Store struct {
+	dir       string
+	workers   int
+	retention time.Duration
+}
+
+// NewStore initializes new instance of Store. The argument 'workers'
+// is set in the configs of the results, so they can be rendered again.
+// The argument 'retention' specifies how long the results are kept, zero
+// means forever. The argument 'frequency' specifies how often the search
+// for expired results occurs. 'errorLog' argument is used to log error
+// messages that may occur during deletion of the results.
+func NewStore(dir string, workers int, retention, frequency time.Duration, errorLog *log.Logger) *Store {
+	s := &Store{
+		dir:       dir,
+		workers:   workers,
+		retention: retention,
+	}
+	if retention > 0 {
+		go s.cleaner(frequency, errorLog)
+	}
+
+	return s
+}
+
+// Save writes the result to the store.
+func (s *Store) Save(r Result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFile(s.path(r.ID), data)
+}
+
+// Get returns the result with the specified ID. If the result
+// doesn't exist, the returned error will be ErrNotFound.
+func (s *Store) Get(id string) (Result, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Result{}, ErrNotFound
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	r := Result{Config: primitive.New(s.workers)}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Result{}, err
+	}
+
+	if s.expired(r) {
+		return Result{}, ErrNotFound
+	}
+	return r, nil
+}
+
+func (s *Store) expired(r Result) bool {
+	return s.retention > 0 && time.Since(r.Created) > s.retention
+}
+
+// cleaner deletes expired results. The duration
+// argument specifies interval between each search.
+func (s *Store) cleaner(d time.Duration, l *log.Logger) {
+	ticker := time.NewTicker(d)
+	for {
+		<-ticker.C
+
+		if err := s.deleteExpired(); err != nil {
+			l.Printf("Error deleting expired results: %s", err)
+		}
+	}
+}
+
+// deleteExpired removes files of the results that are older than retention period.
+func (s *Store) deleteExpired() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.result.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+
+		var r Result
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if s.expired(r) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.result.json", filepath.Base(id)))
+}
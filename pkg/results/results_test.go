@@ -0,0 +1,100 @@
+package results
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+func TestNewID(t *testing.T) {
+	id := NewID(123456789, time.Unix(1621774849, 0))
+	if id != "123456789_1621774849" {
+		t.Errorf("got ID %q; want %q", id, "123456789_1621774849")
+	}
+}
+
+func TestStore_SaveAndGet(t *testing.T) {
+	workers := 2
+	s := NewStore(t.TempDir(), workers, 0, time.Hour, nil)
+	r := Result{
+		ID:      "123456789_1621774849",
+		UserID:  123456789,
+		ImgPath: "inputs/img.jpg",
+		Config:  primitive.New(workers),
+		Scene: primitive.Scene{
+			Version:    primitive.SceneVersion,
+			Width:      10,
+			Height:     5,
+			Scale:      1,
+			Translate:  0.5,
+			Background: "#ffffff",
+			Shapes: []primitive.SceneShape{
+				{
+					Type:     primitive.SceneRectangle,
+					Color:    "#000000",
+					Alpha:    128,
+					Geometry: primitive.RectangleGeometry{X: 1, Y: 1, Width: 2, Height: 2},
+				},
+			},
+		},
+		Created: time.Unix(1621774849, 0).UTC(),
+	}
+	r.Config.Extension = "png"
+
+	if err := s.Save(r); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Get(r.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, r) {
+		t.Errorf("got result %+v;\n want %+v", res, r)
+	}
+}
+
+func TestStore_GetWhenResultDoesNotExist(t *testing.T) {
+	s := NewStore(t.TempDir(), 1, 0, time.Hour, nil)
+
+	_, err := s.Get("123_456")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v; want %v", err, ErrNotFound)
+	}
+}
+
+func TestStore_DeleteExpired(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir, 1, time.Hour, time.Hour, nil)
+
+	results := []Result{
+		{ID: "1_1", UserID: 1, Created: time.Now().Add(-2 * time.Hour)},
+		{ID: "1_2", UserID: 1, Created: time.Now()},
+	}
+	for _, r := range results {
+		if err := s.Save(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// expired result isn't returned even before the deletion
+	if _, err := s.Get("1_1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v; want %v", err, ErrNotFound)
+	}
+
+	if err := s.deleteExpired(); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != "1_2.result.json" {
+		t.Errorf("got files %v; want only %q", paths, "1_2.result.json")
+	}
+}
@@ -0,0 +1,113 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrIncorrectInput is returned when the value entered
+// by the user doesn't match the input dialog.
+var ErrIncorrectInput = errors.New("incorrect input")
+
+// InputKind is the type of the value entered in the input dialog.
+type InputKind int
+
+// Possible types of the values.
+const (
+	// IntInput is an integer between Min and Max.
+	IntInput InputKind = iota
+	// FloatInput is a number between Min and Max.
+	FloatInput
+	// ColorInput is a color in the format #rrggbb.
+	ColorInput
+	// TextInput is a non-empty text.
+	TextInput
+	// EnumInput is one of the Options.
+	EnumInput
+)
+
+// Dialog describes the value that the session waits from the user.
+type Dialog struct {
+	Kind InputKind `json:"kind"`
+	// Field identifies the entered parameter. It is
+	// used to apply the value when the input is accepted.
+	Field string `json:"field"`
+	// Min and Max limit the numbers.
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	// Options contains possible values of the enumeration.
+	Options []string `json:"options,omitempty"`
+}
+
+// Value is the value entered in the input dialog. Only the
+// fields that correspond to the kind of the dialog are set.
+type Value struct {
+	Int   int
+	Float float64
+	// Text contains the text, the option of the
+	// enumeration or the color in the format #rrggbb.
+	Text string
+}
+
+var colorRegex = regexp.MustCompile(`^#?([0-9a-fA-F]{6}|[0-9a-fA-F]{3})$`)
+
+// Parse parses the text entered by the user. If the text doesn't
+// match the dialog, the returned error will be ErrIncorrectInput.
+func (d Dialog) Parse(text string) (Value, error) {
+	text = strings.TrimSpace(text)
+
+	switch d.Kind {
+	case IntInput:
+		n, err := strconv.Atoi(text)
+		if err != nil || float64(n) < d.Min || float64(n) > d.Max {
+			return Value{}, ErrIncorrectInput
+		}
+		return Value{Int: n, Float: float64(n)}, nil
+	case FloatInput:
+		n, err := strconv.ParseFloat(strings.Replace(text, ",", ".", 1), 64)
+		if err != nil || math.IsNaN(n) || n < d.Min || n > d.Max {
+			return Value{}, ErrIncorrectInput
+		}
+		return Value{Float: n}, nil
+	case ColorInput:
+		m := colorRegex.FindStringSubmatch(text)
+		if m == nil {
+			return Value{}, ErrIncorrectInput
+		}
+		hex := strings.ToLower(m[1])
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		return Value{Text: "#" + hex}, nil
+	case TextInput:
+		if text == "" {
+			return Value{}, ErrIncorrectInput
+		}
+		return Value{Text: text}, nil
+	case EnumInput:
+		for i, option := range d.Options {
+			if strings.EqualFold(text, option) {
+				return Value{Int: i, Text: option}, nil
+			}
+		}
+		return Value{}, ErrIncorrectInput
+	}
+
+	return Value{}, fmt.Errorf("unknown input kind %d", d.Kind)
+}
+
+// OpenDialog switches the session to the input dialog.
+func (s *Session) OpenDialog(d Dialog) {
+	s.State = InInputDialog
+	s.Dialog = d
+}
+
+// CloseDialog switches the session back to the menu.
+func (s *Session) CloseDialog() {
+	s.State = InMenu
+	s.Dialog = Dialog{}
+}
@@ -0,0 +1,71 @@
+package sessions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+func TestDialog_Parse(t *testing.T) {
+	tests := []struct {
+		dialog   Dialog
+		text     string
+		expected Value
+	}{
+		{Dialog{Kind: IntInput, Min: 1, Max: 10}, " 10 ", Value{Int: 10, Float: 10}},
+		{Dialog{Kind: FloatInput, Min: 0, Max: 1}, "0,25", Value{Float: 0.25}},
+		{Dialog{Kind: ColorInput}, "#FFA500", Value{Text: "#ffa500"}},
+		{Dialog{Kind: ColorInput}, "fa0", Value{Text: "#ffaa00"}},
+		{Dialog{Kind: TextInput}, "1-10:a=255", Value{Text: "1-10:a=255"}},
+		{Dialog{Kind: EnumInput, Options: []string{"jpg", "png"}}, "PNG", Value{Int: 1, Text: "png"}},
+	}
+
+	for _, tt := range tests {
+		res, err := tt.dialog.Parse(tt.text)
+		if err != nil {
+			t.Errorf("%q: got error %v", tt.text, err)
+			continue
+		}
+		if res != tt.expected {
+			t.Errorf("%q: got value %+v; want %+v", tt.text, res, tt.expected)
+		}
+	}
+}
+
+func TestDialog_ParseWhenIncorrect(t *testing.T) {
+	tests := []struct {
+		dialog Dialog
+		text   string
+	}{
+		{Dialog{Kind: IntInput, Min: 1, Max: 10}, "11"},
+		{Dialog{Kind: IntInput, Min: 1, Max: 10}, "1.5"},
+		{Dialog{Kind: FloatInput, Min: 0, Max: 1}, "NaN"},
+		{Dialog{Kind: FloatInput, Min: 0, Max: 1}, "-0.1"},
+		{Dialog{Kind: ColorInput}, "orange"},
+		{Dialog{Kind: ColorInput}, "#ffa50"},
+		{Dialog{Kind: TextInput}, "  "},
+		{Dialog{Kind: EnumInput, Options: []string{"jpg", "png"}}, "bmp"},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.dialog.Parse(tt.text); !errors.Is(err, ErrIncorrectInput) {
+			t.Errorf("%q: got error %v; want %v", tt.text, err, ErrIncorrectInput)
+		}
+	}
+}
+
+func TestSession_Dialog(t *testing.T) {
+	s := NewSession(123456789, 123, "img.png", testMenu, primitive.New(1))
+	d := Dialog{Kind: IntInput, Field: "iter", Min: 1, Max: 10}
+
+	s.OpenDialog(d)
+	if s.State != InInputDialog || s.Dialog.Field != d.Field {
+		t.Errorf("session = %+v; want %v state with dialog %+v", s, InInputDialog, d)
+	}
+
+	s.CloseDialog()
+	if s.State != InMenu || s.Dialog.Field != "" {
+		t.Errorf("session = %+v; want %v state without dialog", s, InMenu)
+	}
+}
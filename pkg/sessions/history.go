@@ -0,0 +1,62 @@
+package sessions
+
+import "github.com/lazy-void/primitive-bot/pkg/primitive"
+
+// historyLimit is the number of changes of the config that can be undone.
+const historyLimit = 20
+
+// History contains the changes of the config of the session.
+// Undo contains the previous configs, the last one is restored first.
+// Redo contains the configs that were undone.
+type History struct {
+	Undo []primitive.Config
+	Redo []primitive.Config
+}
+
+// record returns the history with the config prev replaced
+// by the new one. Configs that were undone can't be redone
+// after that. The slices are copied, so the sessions that
+// share the original history aren't affected.
+func (h History) record(prev primitive.Config) History {
+	undo := h.Undo
+	if len(undo) >= historyLimit {
+		undo = undo[len(undo)-historyLimit+1:]
+	}
+
+	return History{Undo: push(undo, prev)}
+}
+
+// undo returns the history with the last change undone and
+// the config that was used before it. If there is nothing to
+// undo, the last result is equal to false.
+func (h History) undo(curr primitive.Config) (History, primitive.Config, bool) {
+	n := len(h.Undo)
+	if n == 0 {
+		return h, curr, false
+	}
+
+	return History{
+		Undo: push(h.Undo[:n-1]),
+		Redo: push(h.Redo, curr),
+	}, h.Undo[n-1], true
+}
+
+// redo is the reverse of undo.
+func (h History) redo(curr primitive.Config) (History, primitive.Config, bool) {
+	n := len(h.Redo)
+	if n == 0 {
+		return h, curr, false
+	}
+
+	return History{
+		Undo: push(h.Undo, curr),
+		Redo: push(h.Redo[:n-1]),
+	}, h.Redo[n-1], true
+}
+
+// push returns the copy of the list with the configs appended.
+func push(list []primitive.Config, configs ...primitive.Config) []primitive.Config {
+	res := make([]primitive.Config, 0, len(list)+len(configs))
+	res = append(res, list...)
+	return append(res, configs...)
+}
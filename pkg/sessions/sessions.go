@@ -10,7 +10,6 @@ import (
 	"github.com/lazy-void/primitive-bot/pkg/menu"
 
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
-	"github.com/lazy-void/primitive-bot/pkg/tg"
 )
 
 //go:generate stringer -type=state
@@ -28,118 +27,362 @@ type Session struct {
 	UserID        int64
 	MenuMessageID int64
 	State         state
-	Input         chan tg.Message
-	QuitInput     chan int
 	ImgPath       string
+	Lang          string
 	Menu          menu.Menu
 	Config        primitive.Config
+
+	// Dialog is the input dialog that is open in the InInputDialog state.
+	Dialog Dialog
+	// History contains the changes of the config. It is
+	// maintained by ActiveSessions, so Set ignores it.
+	History History
 }
 
 // NewSession initializes new instance of Session object.
-func NewSession(userID, menuMessageID int64, imgPath string, workers int) Session {
-	c := primitive.New(workers)
-
+// The config and the menu of the session are initialized from c.
+// The menu is made by the builder b and the session keeps its
+// language, so the menu can be updated in the same language.
+func NewSession(userID, menuMessageID int64, imgPath string, b *menu.Builder, c primitive.Config) Session {
 	return Session{
 		lastRequest:   time.Now(),
 		UserID:        userID,
 		MenuMessageID: menuMessageID,
 		State:         InMenu,
-		Input:         make(chan tg.Message),
-		QuitInput:     make(chan int),
 		ImgPath:       imgPath,
-		Menu:          menu.New(c),
+		Lang:          b.Lang(),
+		Menu:          b.New(c),
 		Config:        c,
 	}
 }
 
+// key identifies the session. The IDs of the messages are unique
+// only inside the chat, so the ID of the user is a part of the key.
+type key struct {
+	userID        int64
+	menuMessageID int64
+}
+
+// key returns the key of the session.
+func (s Session) key() key {
+	return key{userID: s.UserID, menuMessageID: s.MenuMessageID}
+}
+
 // ActiveSessions represents list of all active telegram sessions.
+// Sessions are identified by the user and ID of the message with
+// their menu, so each user can have several sessions at once.
 type ActiveSessions struct {
-	sessions map[int64]Session
+	sessions map[key]Session
 	timeout  time.Duration
+	limit    int
+	store    Store
+	errorLog *log.Logger
+	hooks    []func(s Session)
 	mu       sync.Mutex
+	// storeMu orders the writes to the store, so the sessions
+	// aren't accessed in memory while the files are written.
+	storeMu sync.Mutex
 }
 
+// touchInterval is how often the time of the last request is saved when
+// the session is only viewed. It's needed only to expire the session
+// after restart, so it doesn't have to be saved on every request.
+const touchInterval = time.Minute
+
 // NewActiveSessions initializes new instance of ActiveSessions object.
 // The argument 'timeout' specifies the maximum amount of time that a session
 // can be inactive before it is terminated. The argument 'frequency' specifies
-// how often the search for inactive sessions occurs. 'errorLog' argument is used to
-// log error messages that may occur during session termination.
-func NewActiveSessions(timeout time.Duration, frequency time.Duration, errorLog *log.Logger) *ActiveSessions {
+// how often the search for inactive sessions occurs. The argument 'limit'
+// specifies the number of sessions that each user can have at once, zero
+// means no limit. The argument 'store' is used to persist the sessions,
+// if it is nil, the sessions are kept only in memory. 'errorLog' argument
+// is used to log error messages that may occur during session termination
+// and saving of the sessions.
+func NewActiveSessions(
+	timeout, frequency time.Duration,
+	limit int,
+	store Store,
+	errorLog *log.Logger,
+) *ActiveSessions {
 	as := &ActiveSessions{
-		sessions: make(map[int64]Session),
+		sessions: make(map[key]Session),
 		timeout:  timeout,
+		limit:    limit,
+		store:    store,
+		errorLog: errorLog,
 	}
-	go as.timeouter(frequency, errorLog)
+	go as.timeouter(frequency)
 
 	return as
 }
 
-// Set adds new or replaces existing session. The last boolean parameter
-// specifies if it is a completely new session (with new image) or same
-// session that just need to be updated. This is needed to avoid the memory
-// leaks from the goroutines that may be waiting for the user input.
-func (as *ActiveSessions) Set(userID int64, s Session, isNew bool) {
+// Add adds new session. If the user already has the maximum number of
+// sessions, the least recently used one is terminated and returned, so
+// its menu can be deleted. In this case the second result is true.
+func (as *ActiveSessions) Add(s Session) (Session, bool) {
 	as.mu.Lock()
-	defer as.mu.Unlock()
+	var oldest Session
+	n := 0
+	for _, curr := range as.sessions {
+		if curr.UserID != s.UserID {
+			continue
+		}
+		if n == 0 || curr.lastRequest.Before(oldest.lastRequest) {
+			oldest = curr
+		}
+		n++
+	}
 
-	// Delete current session and exit goroutine
-	// that waits for the user input.
-	if curr, ok := as.sessions[userID]; ok && isNew {
-		if curr.State == InInputDialog {
-			select {
-			case curr.QuitInput <- 1:
-				break
-			default:
-				panic("nobody listens on the QuitInput channel")
-			}
+	as.sessions[s.key()] = s
+	evicted := as.limit > 0 && n >= as.limit
+	if evicted {
+		delete(as.sessions, oldest.key())
+	}
+	as.mu.Unlock()
+
+	as.sync(s.key())
+	if !evicted {
+		return Session{}, false
+	}
+	as.sync(oldest.key())
+
+	return oldest, true
+}
+
+// Set replaces existing session of the user with the same menu message ID.
+// If the config of the session is changed, the previous
+// one is added to the history, so it can be undone.
+func (as *ActiveSessions) Set(s Session) {
+	as.mu.Lock()
+	if prev, ok := as.sessions[s.key()]; ok {
+		s.History = prev.History
+		if prev.Config != s.Config {
+			s.History = s.History.record(prev.Config)
 		}
+	}
+	as.sessions[s.key()] = s
+	as.mu.Unlock()
 
-		delete(as.sessions, curr.UserID)
+	as.sync(s.key())
+}
+
+// Undo restores the config of the session of the user with the menu
+// in the message with specified ID that was used before the last change
+// and returns the updated session. If the session doesn't exist or
+// there is nothing to undo, second parameter will be equal to false.
+func (as *ActiveSessions) Undo(userID, menuMessageID int64) (Session, bool) {
+	return as.move(key{userID, menuMessageID}, History.undo)
+}
+
+// Redo restores the config that was undone last. If the session
+// doesn't exist or there is nothing to redo, second parameter
+// will be equal to false.
+func (as *ActiveSessions) Redo(userID, menuMessageID int64) (Session, bool) {
+	return as.move(key{userID, menuMessageID}, History.redo)
+}
+
+func (as *ActiveSessions) move(
+	k key,
+	step func(h History, curr primitive.Config) (History, primitive.Config, bool),
+) (Session, bool) {
+	as.mu.Lock()
+	s, ok := as.sessions[k]
+	if ok {
+		s.History, s.Config, ok = step(s.History, s.Config)
+	}
+	if ok {
+		s, _ = as.touch(s)
 	}
+	as.mu.Unlock()
 
-	as.sessions[userID] = s
+	if !ok {
+		return Session{}, false
+	}
+	as.sync(k)
+	return s, true
 }
 
-// Get returns session of user with specified ID. If the session
-// doesn't exist, second parameter will be equal to false.
-func (as *ActiveSessions) Get(userID int64) (Session, bool) {
+// Get returns session of the user with the menu in the message with specified
+// ID. If the session doesn't exist, second parameter will be equal to false.
+func (as *ActiveSessions) Get(userID, menuMessageID int64) (Session, bool) {
 	as.mu.Lock()
-	defer as.mu.Unlock()
+	s, ok := as.sessions[key{userID, menuMessageID}]
+	stale := false
+	if ok {
+		s, stale = as.touch(s)
+	}
+	as.mu.Unlock()
 
-	s, ok := as.sessions[userID]
 	if !ok {
 		return Session{}, false
 	}
+	if stale {
+		as.sync(s.key())
+	}
+	return s, true
+}
+
+// Len returns the number of the active sessions.
+func (as *ActiveSessions) Len() int {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return len(as.sessions)
+}
+
+// Last returns the most recently used session of the user with specified
+// ID. If the user doesn't have sessions, second parameter will be equal to false.
+func (as *ActiveSessions) Last(userID int64) (Session, bool) {
+	return as.last(userID, func(s Session) bool { return true })
+}
+
+// InputDialog returns the most recently used session of the user with
+// specified ID that waits for the user input. If there isn't such
+// session, second parameter will be equal to false.
+func (as *ActiveSessions) InputDialog(userID int64) (Session, bool) {
+	return as.last(userID, func(s Session) bool { return s.State == InInputDialog })
+}
+
+func (as *ActiveSessions) last(userID int64, accept func(s Session) bool) (Session, bool) {
+	as.mu.Lock()
+	var last Session
+	found := false
+	for _, s := range as.sessions {
+		if s.UserID != userID || !accept(s) {
+			continue
+		}
+		if !found || s.lastRequest.After(last.lastRequest) {
+			last = s
+			found = true
+		}
+	}
+	stale := false
+	if found {
+		last, stale = as.touch(last)
+	}
+	as.mu.Unlock()
+
+	if !found {
+		return Session{}, false
+	}
+	if stale {
+		as.sync(last.key())
+	}
+	return last, true
+}
 
-	// Update info about time of last request
+// touch updates info about time of last request. It reports whether the
+// saved time is older than touchInterval, so the session has to be saved.
+// It must be called with the lock held.
+func (as *ActiveSessions) touch(s Session) (Session, bool) {
+	stale := time.Since(s.lastRequest) > touchInterval
 	s.lastRequest = time.Now()
-	as.sessions[userID] = s
+	as.sessions[s.key()] = s
 
-	return s, true
+	return s, stale
+}
+
+// OnExpire registers the function that is called for each session
+// terminated because of inactivity, e.g. to mark its menu as expired.
+// Hooks are called without the lock held, so they can use ActiveSessions.
+func (as *ActiveSessions) OnExpire(hook func(s Session)) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.hooks = append(as.hooks, hook)
+}
+
+// expire calls hooks for the expired sessions.
+// It must be called without the lock held.
+func (as *ActiveSessions) expire(expired []Session) {
+	as.mu.Lock()
+	hooks := as.hooks
+	as.mu.Unlock()
+
+	for _, s := range expired {
+		for _, hook := range hooks {
+			hook(s)
+		}
+	}
 }
 
 // timeouter terminates inactive sessions. The duration
 // argument specifies interval between each search.
-func (as *ActiveSessions) timeouter(d time.Duration, l *log.Logger) {
+func (as *ActiveSessions) timeouter(d time.Duration) {
 	ticker := time.NewTicker(d)
 	for {
 		<-ticker.C
 
+		var expired []Session
 		as.mu.Lock()
 		for _, s := range as.sessions {
 			if time.Since(s.lastRequest) > as.timeout {
-				if s.State == InInputDialog {
-					select {
-					case s.QuitInput <- 1:
-						break
-					default:
-						l.Println("nobody listens on the QuitInput channel")
-					}
-				}
-
-				delete(as.sessions, s.UserID)
+				delete(as.sessions, s.key())
+				expired = append(expired, s)
 			}
 		}
 		as.mu.Unlock()
+
+		for _, s := range expired {
+			as.sync(s.key())
+		}
+		as.expire(expired)
+	}
+}
+
+// Restore adds the sessions from the store. Sessions that are
+// inactive for too long are deleted and passed to the expiry hooks.
+func (as *ActiveSessions) Restore() error {
+	if as.store == nil {
+		return nil
+	}
+
+	list, err := as.store.Load()
+	if err != nil {
+		return err
+	}
+
+	var expired []Session
+	as.mu.Lock()
+	for _, s := range list {
+		if time.Since(s.lastRequest) > as.timeout {
+			expired = append(expired, s)
+			continue
+		}
+		as.sessions[s.key()] = s
+	}
+	as.mu.Unlock()
+
+	for _, s := range expired {
+		as.sync(s.key())
+	}
+	as.expire(expired)
+	return nil
+}
+
+// sync writes the current version of the session to the store, or
+// deletes it from the store if the session isn't active anymore. The
+// writes are ordered, so the last one always has the latest version.
+// It must be called without the lock held.
+func (as *ActiveSessions) sync(k key) {
+	if as.store == nil {
+		return
+	}
+
+	as.storeMu.Lock()
+	defer as.storeMu.Unlock()
+
+	as.mu.Lock()
+	s, ok := as.sessions[k]
+	as.mu.Unlock()
+
+	if !ok {
+		if err := as.store.Delete(k.userID, k.menuMessageID); err != nil {
+			as.errorLog.Printf("Error deleting session: %s", err)
+		}
+		return
+	}
+	if err := as.store.Save(s); err != nil {
+		as.errorLog.Printf("Error saving session: %s", err)
 	}
 }
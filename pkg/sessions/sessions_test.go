@@ -1,9 +1,7 @@
 package sessions
 
 import (
-	"log"
 	"reflect"
-	"sync"
 	"testing"
 	"time"
 
@@ -14,34 +12,15 @@ import (
 	"github.com/lazy-void/primitive-bot/pkg/primitive"
 )
 
-type out struct {
-	sync.Mutex
-	data string
-}
-
-func (o *out) Write(p []byte) (n int, err error) {
-	o.Lock()
-	defer o.Unlock()
-
-	o.data = string(p)
-	return len(p), nil
-}
-
-func (o *out) Read() string {
-	o.Lock()
-	defer o.Unlock()
-
-	return o.data
-}
+var testMenu = menu.NewBuilder(language.English, message.NewPrinter(language.English), menu.Limits{}, nil)
 
 func TestNewSession(t *testing.T) {
 	var userID, menuMessageID int64 = 123456789, 987654321
 	imgPath := "path/to/image.png"
 	expectedConfig := primitive.New(1)
-	expectedMenu := menu.New(expectedConfig)
-	menu.InitText(message.NewPrinter(language.English))
+	expectedMenu := testMenu.New(expectedConfig)
 
-	s := NewSession(userID, menuMessageID, imgPath, 1)
+	s := NewSession(userID, menuMessageID, imgPath, testMenu, expectedConfig)
 
 	switch {
 	case s.UserID != userID:
@@ -50,13 +29,11 @@ func TestNewSession(t *testing.T) {
 		t.Errorf("session.menuMessageID = %d; want %d", s.MenuMessageID, menuMessageID)
 	case s.State != InMenu:
 		t.Errorf("session.State = %v; want %v", s.State, InMenu)
-	case s.Input == nil:
-		t.Error("session.Input must be non-nil")
-	case s.QuitInput == nil:
-		t.Error("session.QuitInput must be non-nil")
 	case s.ImgPath != imgPath:
 		t.Errorf("session.ImgPath = %v; want %v", s.ImgPath, imgPath)
-	case reflect.DeepEqual(s.Menu, expectedMenu):
+	case s.Lang != "en":
+		t.Errorf("session.Lang = %s; want %s", s.Lang, "en")
+	case !reflect.DeepEqual(s.Menu, expectedMenu):
 		t.Errorf("session.Menu = %+v; want %+v", s.Menu, expectedMenu)
 	case s.Config != expectedConfig:
 		t.Errorf("session.Config = %+v; want %+v", s.Config, expectedConfig)
@@ -67,19 +44,19 @@ func TestNewActiveSessionsStartsTimeouterThatTerminatesInactiveSessions(t *testi
 	timeout := 10 * time.Millisecond
 	frequency := 5 * time.Millisecond
 	var userID int64 = 123456789
-	session := NewSession(userID, 123, "img.png", 1)
+	session := NewSession(userID, 123, "img.png", testMenu, primitive.New(1))
 
 	// create
-	as := NewActiveSessions(timeout, frequency, nil)
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
 
 	// add session
-	as.Set(userID, session, false)
+	as.Set(session)
 
 	// wait
 	time.Sleep(timeout + frequency)
 
 	// check that session is terminated
-	if _, ok := as.Get(userID); ok {
+	if _, ok := as.Get(session.UserID, session.MenuMessageID); ok {
 		t.Error("inactive session wasn't terminated.")
 	}
 }
@@ -88,99 +65,54 @@ func TestNewActiveSessionsStartsTimeouterThatDoesNotTerminateActiveSessions(t *t
 	timeout := 50 * time.Millisecond
 	frequency := 10 * time.Millisecond
 	var userID int64 = 123456789
-	session := NewSession(userID, 123, "img.png", 1)
+	session := NewSession(userID, 123, "img.png", testMenu, primitive.New(1))
 
 	// create
-	as := NewActiveSessions(timeout, frequency, nil)
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
 
 	// add session
-	as.Set(userID, session, false)
+	as.Set(session)
 
 	// wait
 	time.Sleep(timeout / 2)
 
 	// update time of last request
-	as.Get(userID)
+	as.Get(session.UserID, session.MenuMessageID)
 
 	// wait
 	time.Sleep(frequency + timeout/2)
 
 	// check that session wasn't terminated
-	if _, ok := as.Get(userID); !ok {
+	if _, ok := as.Get(session.UserID, session.MenuMessageID); !ok {
 		t.Error("active session was terminated.")
 	}
 }
 
-func TestNewActiveSessionsWhenTerminatedSessionIsInInputMenuState(t *testing.T) {
-	timeout := time.Millisecond
-	frequency := time.Millisecond
-	var userID int64 = 123456789
-	session := NewSession(userID, 123, "img.png", 1)
-	session.State = InInputDialog
-
-	// create
-	as := NewActiveSessions(timeout, frequency, nil)
-
-	// add session
-	as.Set(userID, session, false)
-
-	// wait for signal from quit channel
-	after := time.After(100 * timeout)
-	select {
-	case <-after:
-		t.Error("signal on quit channel was not sent.")
-	case <-session.QuitInput:
-	}
-}
-
-func TestTimeouterWhenTerminatedSessionIsInInputMenuStateButNobodyListensQuitChannelLogsAnError(t *testing.T) {
-	timeout := time.Millisecond
-	frequency := time.Millisecond
-	var userID int64 = 123456789
-	session := NewSession(userID, 123, "img.png", 1)
-	session.State = InInputDialog
-	expected := "nobody listens on the QuitInput channel\n"
-
-	// create and add session
-	as := &ActiveSessions{
-		sessions: make(map[int64]Session),
-		timeout:  timeout,
-	}
-	as.Set(userID, session, false)
-
-	logOut := &out{}
-	go as.timeouter(frequency, log.New(logOut, "", 0))
-	time.Sleep(frequency * 10)
-
-	res := logOut.Read()
-	if res != expected {
-		t.Errorf("got log message: %q; want %q", res, expected)
-	}
-}
-
 func TestActiveSessions_Set(t *testing.T) {
 	timeout := 100 * time.Second
 	frequency := 100 * time.Second
 	var userID int64 = 123456789
-	session := NewSession(userID, 123, "img.png", 1)
+	session := NewSession(userID, 123, "img.png", testMenu, primitive.New(1))
 
-	as := NewActiveSessions(timeout, frequency, nil)
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
 
 	// add session
-	as.Set(userID, session, false)
+	as.Set(session)
 
-	s, ok := as.sessions[userID]
+	s, ok := as.sessions[session.key()]
 	if !ok {
 		t.Error("session isn't in the active sessions.")
 	} else if !reflect.DeepEqual(s, session) {
 		t.Errorf("session = %+v; want %+v ", s, session)
 	}
 
-	// update session
-	session.Config.Shape = primitive.ShapeEllipse
-	as.Set(userID, session, false)
+	// update session, the previous config is added to the history
+	prev := session.Config
+	session.Config.Shapes = primitive.NewShapeSet(primitive.ShapeEllipse)
+	as.Set(session)
+	session.History = History{Undo: []primitive.Config{prev}}
 
-	s, ok = as.sessions[userID]
+	s, ok = as.sessions[session.key()]
 	if !ok {
 		t.Error("session isn't in the active sessions.")
 	} else if !reflect.DeepEqual(s, session) {
@@ -192,23 +124,202 @@ func TestActiveSessions_Get(t *testing.T) {
 	timeout := 100 * time.Second
 	frequency := 100 * time.Second
 	var userID int64 = 123456789
-	session := NewSession(userID, 123, "img.png", 1)
+	session := NewSession(userID, 123, "img.png", testMenu, primitive.New(1))
 
-	as := NewActiveSessions(timeout, frequency, nil)
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
 
 	// when session is not in the active session
-	_, ok := as.Get(userID)
+	_, ok := as.Get(session.UserID, session.MenuMessageID)
 	if ok {
 		t.Error("session mustn't be in the active sessions.")
 	}
 
 	// add session
-	as.Set(userID, session, false)
+	as.Set(session)
 
-	s, ok := as.Get(userID)
+	s, ok := as.Get(session.UserID, session.MenuMessageID)
 	if !ok {
 		t.Error("session must be in the active sessions.")
 	} else if reflect.DeepEqual(s, session) {
 		t.Errorf("session = %+v; want %+v ", s, session)
 	}
 }
+
+func TestActiveSessions_SameMessageIDOfDifferentUsers(t *testing.T) {
+	as := NewActiveSessions(100*time.Second, 100*time.Second, 0, nil, nil)
+
+	// the message IDs are unique only inside the chat
+	first := NewSession(1, 123, "first.png", testMenu, primitive.New(1))
+	second := NewSession(2, 123, "second.png", testMenu, primitive.New(1))
+	as.Add(first)
+	as.Add(second)
+
+	for _, session := range []Session{first, second} {
+		s, ok := as.Get(session.UserID, session.MenuMessageID)
+		if !ok || s.ImgPath != session.ImgPath {
+			t.Errorf("Get(%d, %d) = %+v, %t; want the session with %s",
+				session.UserID, session.MenuMessageID, s, ok, session.ImgPath)
+		}
+	}
+	if _, ok := as.Get(3, 123); ok {
+		t.Error("the session of the other user is returned")
+	}
+}
+
+func TestActiveSessions_Add(t *testing.T) {
+	timeout := 100 * time.Second
+	frequency := 100 * time.Second
+	var userID int64 = 123456789
+
+	as := NewActiveSessions(timeout, frequency, 2, nil, nil)
+
+	first := NewSession(userID, 1, "img1.png", testMenu, primitive.New(1))
+	second := NewSession(userID, 2, "img2.png", testMenu, primitive.New(1))
+	other := NewSession(987654321, 3, "img3.png", testMenu, primitive.New(1))
+	for _, s := range []Session{first, second, other} {
+		if _, ok := as.Add(s); ok {
+			t.Fatalf("session %d was terminated before the limit is reached.", s.MenuMessageID)
+		}
+	}
+
+	// the first session is the least recently used one
+	time.Sleep(time.Millisecond)
+	as.Get(second.UserID, second.MenuMessageID)
+
+	third := NewSession(userID, 4, "img4.png", testMenu, primitive.New(1))
+	evicted, ok := as.Add(third)
+	if !ok || evicted.MenuMessageID != first.MenuMessageID {
+		t.Errorf("terminated session = %+v, %t; want the first one", evicted, ok)
+	}
+	if _, ok := as.Get(first.UserID, first.MenuMessageID); ok {
+		t.Error("terminated session mustn't be in the active sessions.")
+	}
+	for _, s := range []Session{second, third, other} {
+		if _, ok := as.Get(s.UserID, s.MenuMessageID); !ok {
+			t.Errorf("session %d must be in the active sessions.", s.MenuMessageID)
+		}
+	}
+}
+
+func TestActiveSessions_Last(t *testing.T) {
+	timeout := 100 * time.Second
+	frequency := 100 * time.Second
+	var userID int64 = 123456789
+
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
+
+	// when user doesn't have sessions
+	if _, ok := as.Last(userID); ok {
+		t.Error("user mustn't have sessions.")
+	}
+
+	first := NewSession(userID, 1, "img1.png", testMenu, primitive.New(1))
+	first.State = InInputDialog
+	second := NewSession(userID, 2, "img2.png", testMenu, primitive.New(1))
+	as.Add(first)
+	time.Sleep(time.Millisecond)
+	as.Add(second)
+
+	if s, ok := as.Last(userID); !ok || s.MenuMessageID != second.MenuMessageID {
+		t.Errorf("last session = %d; want %d", s.MenuMessageID, second.MenuMessageID)
+	}
+	if s, ok := as.InputDialog(userID); !ok || s.MenuMessageID != first.MenuMessageID {
+		t.Errorf("session with input dialog = %d; want %d", s.MenuMessageID, first.MenuMessageID)
+	}
+}
+
+func TestActiveSessions_OnExpire(t *testing.T) {
+	timeout := 10 * time.Millisecond
+	frequency := 5 * time.Millisecond
+	session := NewSession(123456789, 123, "img.png", testMenu, primitive.New(1))
+
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
+	expired := make(chan Session, 1)
+	as.OnExpire(func(s Session) {
+		// hooks can use active sessions
+		as.Get(s.UserID, s.MenuMessageID)
+		expired <- s
+	})
+	as.Add(session)
+
+	select {
+	case s := <-expired:
+		if s.MenuMessageID != session.MenuMessageID {
+			t.Errorf("expired session = %d; want %d", s.MenuMessageID, session.MenuMessageID)
+		}
+	case <-time.After(100 * timeout):
+		t.Error("hook wasn't called for the inactive session.")
+	}
+}
+
+func TestActiveSessions_Undo(t *testing.T) {
+	timeout := 100 * time.Second
+	frequency := 100 * time.Second
+	session := NewSession(123456789, 123, "img.png", testMenu, primitive.New(1))
+
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
+	as.Add(session)
+
+	// nothing to undo or redo
+	if _, ok := as.Undo(session.UserID, session.MenuMessageID); ok {
+		t.Error("undo of the new session must fail.")
+	}
+	if _, ok := as.Redo(session.UserID, session.MenuMessageID); ok {
+		t.Error("redo of the new session must fail.")
+	}
+
+	first := session.Config
+	session.Config.Iterations = 500
+	as.Set(session)
+	second := session.Config
+	session.Config.Repeat = 3
+	as.Set(session)
+	third := session.Config
+
+	for _, expected := range []primitive.Config{second, first} {
+		s, ok := as.Undo(session.UserID, session.MenuMessageID)
+		if !ok || s.Config != expected {
+			t.Errorf("undo: config = %+v, %t; want %+v", s.Config, ok, expected)
+		}
+	}
+	if _, ok := as.Undo(session.UserID, session.MenuMessageID); ok {
+		t.Error("undo must fail when the history is empty.")
+	}
+
+	s, ok := as.Redo(session.UserID, session.MenuMessageID)
+	if !ok || s.Config != second {
+		t.Errorf("redo: config = %+v, %t; want %+v", s.Config, ok, second)
+	}
+
+	// the new change discards configs that can be redone
+	s.Config.Alpha = 255
+	as.Set(s)
+	if _, ok := as.Redo(session.UserID, session.MenuMessageID); ok {
+		t.Errorf("redo of %+v must fail after the new change.", third)
+	}
+	if s, ok := as.Undo(session.UserID, session.MenuMessageID); !ok || s.Config != second {
+		t.Errorf("undo: config = %+v, %t; want %+v", s.Config, ok, second)
+	}
+}
+
+func TestActiveSessions_SetLimitsHistory(t *testing.T) {
+	timeout := 100 * time.Second
+	frequency := 100 * time.Second
+	session := NewSession(123456789, 123, "img.png", testMenu, primitive.New(1))
+
+	as := NewActiveSessions(timeout, frequency, 0, nil, nil)
+	as.Add(session)
+	for i := 1; i <= historyLimit+5; i++ {
+		session.Config.Iterations = i
+		as.Set(session)
+	}
+
+	s, _ := as.Get(session.UserID, session.MenuMessageID)
+	if n := len(s.History.Undo); n != historyLimit {
+		t.Fatalf("len(History.Undo) = %d; want %d", n, historyLimit)
+	}
+	// the oldest changes are discarded
+	if n := s.History.Undo[0].Iterations; n != 5 {
+		t.Errorf("the oldest config has %d iterations; want 5", n)
+	}
+}
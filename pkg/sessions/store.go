@@ -0,0 +1,194 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/internal/fileutil"
+	"github.com/lazy-void/primitive-bot/pkg/menu"
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+// Store persists sessions, so they can be restored after restart.
+type Store interface {
+	// Save writes the session replacing the previous
+	// version of the session with the same menu.
+	Save(s Session) error
+	// Delete removes the session of the user with the menu in the message
+	// with specified ID. Deleting the missing session isn't an error.
+	Delete(userID, menuMessageID int64) error
+	// Load returns all saved sessions.
+	Load() ([]Session, error)
+}
+
+// record contains the part of the session that is persisted.
+type record struct {
+	UserID        int64            `json:"user_id"`
+	MenuMessageID int64            `json:"menu_message_id"`
+	State         state            `json:"state"`
+	Dialog        Dialog           `json:"dialog"`
+	ImgPath       string           `json:"img_path"`
+	Lang          string           `json:"lang"`
+	Menu          menu.Menu        `json:"menu"`
+	Config        primitive.Config `json:"config"`
+	History       historyRecord    `json:"history"`
+	LastRequest   time.Time        `json:"last_request"`
+}
+
+// historyRecord contains the configs of the history as raw JSON, so
+// they can be unmarshalled over the config with the number of workers.
+type historyRecord struct {
+	Undo []json.RawMessage `json:"undo"`
+	Redo []json.RawMessage `json:"redo"`
+}
+
+func newHistoryRecord(h History) (historyRecord, error) {
+	var r historyRecord
+	var err error
+	if r.Undo, err = marshalConfigs(h.Undo); err != nil {
+		return historyRecord{}, err
+	}
+	if r.Redo, err = marshalConfigs(h.Redo); err != nil {
+		return historyRecord{}, err
+	}
+	return r, nil
+}
+
+func (r historyRecord) history(workers int) (History, error) {
+	var h History
+	var err error
+	if h.Undo, err = unmarshalConfigs(r.Undo, workers); err != nil {
+		return History{}, err
+	}
+	if h.Redo, err = unmarshalConfigs(r.Redo, workers); err != nil {
+		return History{}, err
+	}
+	return h, nil
+}
+
+func marshalConfigs(configs []primitive.Config) ([]json.RawMessage, error) {
+	list := make([]json.RawMessage, len(configs))
+	for i, c := range configs {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = data
+	}
+	return list, nil
+}
+
+func unmarshalConfigs(list []json.RawMessage, workers int) ([]primitive.Config, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	configs := make([]primitive.Config, len(list))
+	for i, data := range list {
+		configs[i] = primitive.New(workers)
+		if err := json.Unmarshal(data, &configs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// FileStore keeps each session as a separate JSON file in the directory.
+type FileStore struct {
+	dir     string
+	workers int
+}
+
+// NewFileStore initializes new instance of FileStore. The configs of the
+// restored sessions and of their history get the number of workers 'workers'.
+func NewFileStore(dir string, workers int) *FileStore {
+	return &FileStore{
+		dir:     dir,
+		workers: workers,
+	}
+}
+
+// Save implements Store.
+func (fs *FileStore) Save(s Session) error {
+	history, err := newHistoryRecord(s.History)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record{
+		UserID:        s.UserID,
+		MenuMessageID: s.MenuMessageID,
+		State:         s.State,
+		Dialog:        s.Dialog,
+		ImgPath:       s.ImgPath,
+		Lang:          s.Lang,
+		Menu:          s.Menu,
+		Config:        s.Config,
+		History:       history,
+		LastRequest:   s.lastRequest,
+	})
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFile(fs.path(s.UserID, s.MenuMessageID), data)
+}
+
+// Delete implements Store.
+func (fs *FileStore) Delete(userID, menuMessageID int64) error {
+	err := os.Remove(fs.path(userID, menuMessageID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Load implements Store.
+func (fs *FileStore) Load() ([]Session, error) {
+	paths, err := filepath.Glob(filepath.Join(fs.dir, "*.session.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Session, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+
+		r := record{Config: primitive.New(fs.workers)}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		history, err := r.History.history(fs.workers)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		list = append(list, Session{
+			lastRequest:   r.LastRequest,
+			UserID:        r.UserID,
+			MenuMessageID: r.MenuMessageID,
+			State:         r.State,
+			Dialog:        r.Dialog,
+			ImgPath:       r.ImgPath,
+			Lang:          r.Lang,
+			Menu:          r.Menu,
+			Config:        r.Config,
+			History:       history,
+		})
+	}
+
+	return list, nil
+}
+
+// path returns the path of the file of the session. The IDs of the messages
+// are unique only inside the chat, so the file is named after the user too.
+func (fs *FileStore) path(userID, menuMessageID int64) string {
+	return filepath.Join(fs.dir, fmt.Sprintf("%d_%d.session.json", userID, menuMessageID))
+}
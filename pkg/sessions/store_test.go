@@ -0,0 +1,142 @@
+package sessions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+func TestFileStore(t *testing.T) {
+	workers := 2
+	c := primitive.New(workers)
+	c.Shapes = primitive.NewShapeSet(primitive.ShapeCircle)
+	c.Schedule = "1-10:a=255"
+	session := NewSession(123456789, 1, "img.png", testMenu, c)
+	session.OpenDialog(Dialog{Kind: EnumInput, Field: "ext", Options: []string{"jpg", "png"}})
+	session.Menu.ShapesView = testMenu.NewShapesView(c.Shapes)
+	prev := primitive.New(workers)
+	session.History = History{Undo: []primitive.Config{prev}, Redo: []primitive.Config{c, prev}}
+	session.lastRequest = session.lastRequest.Round(0)
+
+	fs := NewFileStore(t.TempDir(), workers)
+	if err := fs.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := fs.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("got %d sessions; want 1", len(list))
+	}
+
+	res := list[0]
+	if !res.lastRequest.Equal(session.lastRequest) {
+		t.Errorf("session.lastRequest = %v; want %v", res.lastRequest, session.lastRequest)
+	}
+	res.lastRequest = session.lastRequest
+	if !reflect.DeepEqual(res, session) {
+		t.Errorf("session = %+v;\n want %+v", res, session)
+	}
+
+	if err := fs.Delete(session.UserID, session.MenuMessageID); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := fs.Load(); err != nil || len(list) != 0 {
+		t.Errorf("got sessions %+v and error %v; want no sessions", list, err)
+	}
+	// deleting missing session isn't an error
+	if err := fs.Delete(session.UserID, session.MenuMessageID); err != nil {
+		t.Errorf("got error %v; want nil", err)
+	}
+}
+
+func TestFileStore_SameMessageIDOfDifferentUsers(t *testing.T) {
+	dir := t.TempDir()
+	fs := NewFileStore(dir, 1)
+	first := NewSession(1, 123, "first.png", testMenu, primitive.New(1))
+	second := NewSession(2, 123, "second.png", testMenu, primitive.New(1))
+	for _, s := range []Session{first, second} {
+		if err := fs.Save(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := fs.Delete(first.UserID, first.MenuMessageID); err != nil {
+		t.Fatal(err)
+	}
+	list, err := fs.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ImgPath != second.ImgPath {
+		t.Errorf("got sessions %+v; want only the session with %s", list, second.ImgPath)
+	}
+}
+
+func TestActiveSessions_Restore(t *testing.T) {
+	timeout := 100 * time.Second
+	frequency := 100 * time.Second
+	fs := NewFileStore(t.TempDir(), 1)
+
+	as := NewActiveSessions(timeout, frequency, 0, fs, nil)
+	inMenu := NewSession(1, 1, "img1.png", testMenu, primitive.New(1))
+	inInput := NewSession(1, 2, "img2.png", testMenu, primitive.New(1))
+	expired := NewSession(2, 3, "img3.png", testMenu, primitive.New(1))
+	expired.lastRequest = time.Now().Add(-2 * timeout)
+	as.Add(inMenu)
+	as.Add(inInput)
+	as.Add(expired)
+	inInput.OpenDialog(Dialog{Kind: IntInput, Field: "iter", Min: 1, Max: 10})
+	as.Set(inInput)
+
+	// restart
+	as = NewActiveSessions(timeout, frequency, 0, fs, nil)
+	var expiredIDs []int64
+	as.OnExpire(func(s Session) { expiredIDs = append(expiredIDs, s.MenuMessageID) })
+	if err := as.Restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, ok := as.Get(inMenu.UserID, inMenu.MenuMessageID); !ok || s.State != InMenu {
+		t.Errorf("session %d must be restored in the %v state.", inMenu.MenuMessageID, InMenu)
+	}
+	// input dialogs survive the restart
+	s, ok := as.Get(inInput.UserID, inInput.MenuMessageID)
+	if !ok || s.State != InInputDialog || !reflect.DeepEqual(s.Dialog, inInput.Dialog) {
+		t.Errorf("session = %+v; want session %d with dialog %+v", s, inInput.MenuMessageID, inInput.Dialog)
+	}
+	if _, ok := as.Get(expired.UserID, expired.MenuMessageID); ok {
+		t.Error("expired session mustn't be restored.")
+	}
+	if len(expiredIDs) != 1 || expiredIDs[0] != expired.MenuMessageID {
+		t.Errorf("expired sessions = %v; want %d", expiredIDs, expired.MenuMessageID)
+	}
+	if list, _ := fs.Load(); len(list) != 2 {
+		t.Errorf("got %d sessions in the store; want 2", len(list))
+	}
+}
+
+func TestActiveSessions_GetSavesTimeOfLastRequest(t *testing.T) {
+	fs := NewFileStore(t.TempDir(), 1)
+	as := NewActiveSessions(time.Hour, time.Hour, 0, fs, nil)
+
+	// the session was viewed last time long ago
+	s := NewSession(1, 123, "img.png", testMenu, primitive.New(1))
+	s.lastRequest = time.Now().Add(-30 * time.Minute)
+	as.Set(s)
+
+	as.Get(s.UserID, s.MenuMessageID)
+
+	// the session isn't expired after restart
+	list, err := fs.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || time.Since(list[0].lastRequest) > touchInterval {
+		t.Errorf("got sessions %+v; want the session viewed just now", list)
+	}
+}
@@ -0,0 +1,176 @@
+// Package styles implements artistic style presets. Each style
+// sets several parameters of the primitive.Config at once.
+package styles
+
+import (
+	// embed is needed for the built-in styles
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+//go:embed styles.json
+var builtin []byte
+
+var idRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Style is a preset of the parameters of the primitive.Config. Name and
+// Description are mappings from the language code to the text. Parameters
+// with zero values (or nil for Repeat and Alpha) aren't changed by the style.
+// Schedule is always replaced, so the empty one removes the schedule.
+type Style struct {
+	ID          string            `json:"id"`
+	Name        map[string]string `json:"name"`
+	Description map[string]string `json:"description"`
+	Shapes      string            `json:"shapes,omitempty"`
+	Iterations  int               `json:"iterations,omitempty"`
+	Repeat      *int              `json:"repeat,omitempty"`
+	Alpha       *int              `json:"alpha,omitempty"`
+	Schedule    string            `json:"schedule,omitempty"`
+}
+
+// Load returns the built-in styles and the styles from the JSON files
+// in the directory dir. Each file contains the list of the styles. The style
+// from the directory replaces the built-in one with the same ID. If dir
+// is empty, only the built-in styles are returned.
+func Load(dir string) ([]Style, error) {
+	styles, err := parse(builtin)
+	if err != nil {
+		return nil, fmt.Errorf("built-in styles: %w", err)
+	}
+	if dir == "" {
+		return styles, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+
+		custom, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		styles = merge(styles, custom)
+	}
+
+	return styles, nil
+}
+
+// Find returns the style with the ID. If the style doesn't exist,
+// second parameter will be equal to false.
+func Find(styles []Style, id string) (Style, bool) {
+	for _, s := range styles {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Style{}, false
+}
+
+func parse(data []byte) ([]Style, error) {
+	var styles []Style
+	if err := json.Unmarshal(data, &styles); err != nil {
+		return nil, err
+	}
+
+	for _, s := range styles {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("style %q: %w", s.ID, err)
+		}
+	}
+	return styles, nil
+}
+
+// merge adds the styles to the list replacing the ones with the same ID.
+func merge(styles, other []Style) []Style {
+	for _, s := range other {
+		replaced := false
+		for i := range styles {
+			if styles[i].ID == s.ID {
+				styles[i] = s
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			styles = append(styles, s)
+		}
+	}
+	return styles
+}
+
+func (s Style) validate() error {
+	if !idRegex.MatchString(s.ID) {
+		return fmt.Errorf("incorrect ID")
+	}
+	if s.Name["en"] == "" {
+		return fmt.Errorf("english name is missing")
+	}
+
+	if s.Shapes != "" {
+		if _, err := primitive.ParseShapeSet(s.Shapes); err != nil {
+			return err
+		}
+	}
+	if s.Iterations < 0 {
+		return fmt.Errorf("incorrect number of iterations %d", s.Iterations)
+	}
+	if s.Repeat != nil && *s.Repeat < 0 {
+		return fmt.Errorf("incorrect repeat %d", *s.Repeat)
+	}
+	if s.Alpha != nil && (*s.Alpha < 0 || *s.Alpha > 255) {
+		return fmt.Errorf("incorrect alpha %d", *s.Alpha)
+	}
+	if _, err := primitive.ParseSchedule(s.Schedule); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Text returns the name and the description of the style in
+// the language lang. If there is no translation, english is used.
+func (s Style) Text(lang string) (string, string) {
+	name, ok := s.Name[lang]
+	if !ok {
+		name = s.Name["en"]
+	}
+
+	description, ok := s.Description[lang]
+	if !ok {
+		description = s.Description["en"]
+	}
+	return name, description
+}
+
+// Apply returns the config with the parameters of the style.
+func (s Style) Apply(c primitive.Config) primitive.Config {
+	if shapes, err := primitive.ParseShapeSet(s.Shapes); err == nil {
+		c.Shapes = shapes
+	}
+	if s.Iterations > 0 {
+		c.Iterations = s.Iterations
+	}
+	if s.Repeat != nil {
+		c.Repeat = *s.Repeat
+	}
+	if s.Alpha != nil {
+		c.Alpha = *s.Alpha
+	}
+	// the schedule is validated when the style is loaded
+	schedule, _ := primitive.ParseSchedule(s.Schedule)
+	c.Schedule = schedule.String()
+
+	return c
+}
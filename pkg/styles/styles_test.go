@@ -0,0 +1,122 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lazy-void/primitive-bot/pkg/primitive"
+)
+
+func TestLoadBuiltinStyles(t *testing.T) {
+	styles, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"lowpoly", "pointillism", "bauhaus", "sketch", "stained_glass"} {
+		s, ok := Find(styles, id)
+		if !ok {
+			t.Errorf("style %q isn't found", id)
+			continue
+		}
+
+		for _, lang := range []string{"en", "ru"} {
+			if s.Name[lang] == "" || s.Description[lang] == "" {
+				t.Errorf("style %q doesn't have %s translation", id, lang)
+			}
+		}
+	}
+}
+
+func TestLoadCustomStyles(t *testing.T) {
+	dir := t.TempDir()
+	data := `[
+		{"id": "lowpoly", "name": {"en": "Custom Low-poly"}, "iterations": 50},
+		{"id": "custom", "name": {"en": "Custom"}, "shapes": "3", "schedule": "1-10: a=255"}
+	]`
+	if err := os.WriteFile(filepath.Join(dir, "custom.json"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	styles, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, ok := Find(styles, "lowpoly")
+	if !ok || s.Name["en"] != "Custom Low-poly" {
+		t.Errorf("built-in style isn't replaced: %+v", s)
+	}
+	if _, ok := Find(styles, "custom"); !ok {
+		t.Error("custom style isn't found")
+	}
+}
+
+func TestLoadWhenStyleIsIncorrect(t *testing.T) {
+	tests := map[string]string{
+		"Incorrect ID":       `[{"id": "Low Poly", "name": {"en": "Low-poly"}}]`,
+		"No english name":    `[{"id": "lowpoly", "name": {"ru": "Low-poly"}}]`,
+		"Incorrect shapes":   `[{"id": "lowpoly", "name": {"en": "Low-poly"}, "shapes": "10"}]`,
+		"Incorrect alpha":    `[{"id": "lowpoly", "name": {"en": "Low-poly"}, "alpha": 300}]`,
+		"Incorrect schedule": `[{"id": "lowpoly", "name": {"en": "Low-poly"}, "schedule": "1-10"}]`,
+		"Incorrect JSON":     `{"id": "lowpoly"}`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "custom.json"), []byte(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Load(dir); err == nil {
+				t.Error("expected error for the incorrect style")
+			}
+		})
+	}
+}
+
+func TestStyle_Text(t *testing.T) {
+	s := Style{
+		Name:        map[string]string{"en": "Sketch", "ru": "Набросок"},
+		Description: map[string]string{"en": "pencil drawing"},
+	}
+
+	name, description := s.Text("ru")
+	if name != "Набросок" || description != "pencil drawing" {
+		t.Errorf("got %q and %q; want %q and %q", name, description, "Набросок", "pencil drawing")
+	}
+}
+
+func TestStyle_Apply(t *testing.T) {
+	repeat, alpha := 0, 255
+	s := Style{
+		Shapes:     "1+8",
+		Iterations: 400,
+		Repeat:     &repeat,
+		Alpha:      &alpha,
+		Schedule:   "1-100: a=255",
+	}
+	c := primitive.New(1)
+	c.OutputSize = 512
+	c.Schedule = "1-10:r=2"
+
+	expected := c
+	expected.Shapes = primitive.NewShapeSet(primitive.ShapeTriangle, primitive.ShapePolygon)
+	expected.Iterations = 400
+	expected.Repeat = 0
+	expected.Alpha = 255
+	expected.Schedule = "1-100:a=255"
+
+	if res := s.Apply(c); res != expected {
+		t.Errorf("got config %+v; want %+v", res, expected)
+	}
+
+	// parameters that aren't specified don't change except the schedule
+	expected = c
+	expected.Schedule = ""
+	if res := (Style{}).Apply(c); res != expected {
+		t.Errorf("got config %+v; want %+v", res, expected)
+	}
+}
@@ -97,6 +97,25 @@ func (b *Bot) EditMessageText(chatID, messageID int64, text string, keyboard ...
 	return nil
 }
 
+// EditMessageReplyMarkup implements Telegram's editMessageReplyMarkup method.
+func (b *Bot) EditMessageReplyMarkup(chatID, messageID int64, keyboard InlineKeyboardMarkup) error {
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"chat_id":      chatID,
+		"message_id":   messageID,
+		"reply_markup": keyboard,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = b.makeRequest("/editMessageReplyMarkup", jsonContentType, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // SendMessage implements Telegram's sendMessage method.
 func (b *Bot) SendMessage(chatID int64, message string, keyboard ...InlineKeyboardMarkup) (Message, error) {
 	params := map[string]interface{}{
@@ -132,7 +151,8 @@ func (b *Bot) SendMessage(chatID int64, message string, keyboard ...InlineKeyboa
 }
 
 // SendDocument implements Telegram's sendDocument method.
-func (b *Bot) SendDocument(chatID int64, documentPath string) error {
+// Empty caption isn't sent.
+func (b *Bot) SendDocument(chatID int64, documentPath, caption string, keyboard ...InlineKeyboardMarkup) error {
 	w, formBody, err := createMultipartForm("document", documentPath)
 	if err != nil {
 		return err
@@ -145,6 +165,16 @@ func (b *Bot) SendDocument(chatID int64, documentPath string) error {
 
 	q := url.Values{}
 	q.Set("chat_id", fmt.Sprint(chatID))
+	if caption != "" {
+		q.Set("caption", caption)
+	}
+	if len(keyboard) > 0 {
+		markup, err := json.Marshal(keyboard[0])
+		if err != nil {
+			return err
+		}
+		q.Set("reply_markup", string(markup))
+	}
 	u.RawQuery = q.Encode()
 
 	resp, err := http.Post(u.String(), w.FormDataContentType(), formBody)
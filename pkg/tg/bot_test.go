@@ -86,7 +86,47 @@ func TestBot_SendDocument(t *testing.T) {
 	}
 	defer os.Remove(path)
 
-	err = bot.SendDocument(chatID, path)
+	err = bot.SendDocument(chatID, path, "")
+	if err != nil {
+		t.Errorf("Error sending document: %v", err)
+	}
+}
+
+func TestBot_SendDocumentWhenWithKeyboard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	bot := getBot(t)
+
+	path := "test_file.txt"
+	err := os.WriteFile(path, []byte("Hello World"), 0600)
+	if err != nil {
+		t.Fatalf("Error while creating test file: %v", err)
+	}
+	defer os.Remove(path)
+
+	err = bot.SendDocument(chatID, path, "", InlineKeyboardMarkup{
+		InlineKeyboard: [][]InlineKeyboardButton{{{Text: "Hello", CallbackData: "hello"}}},
+	})
+	if err != nil {
+		t.Errorf("Error sending document: %v", err)
+	}
+}
+
+func TestBot_SendDocumentWhenWithCaption(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	bot := getBot(t)
+
+	path := "test_file.txt"
+	err := os.WriteFile(path, []byte("Hello World"), 0600)
+	if err != nil {
+		t.Fatalf("Error while creating test file: %v", err)
+	}
+	defer os.Remove(path)
+
+	err = bot.SendDocument(chatID, path, "Test caption")
 	if err != nil {
 		t.Errorf("Error sending document: %v", err)
 	}
@@ -100,7 +140,7 @@ func TestBot_SendDocumentWhenPathIsIncorrect(t *testing.T) {
 
 	path := "test_file.txt"
 
-	err := bot.SendDocument(chatID, path)
+	err := bot.SendDocument(chatID, path, "")
 	if err != nil && !strings.Contains(err.Error(), "no such file or directory") {
 		t.Errorf("Error sending non-existing document: %v", err)
 	}
@@ -125,6 +165,30 @@ func TestBot_EditMessageText(t *testing.T) {
 	}
 }
 
+func TestBot_EditMessageReplyMarkup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	bot := getBot(t)
+
+	// send message
+	msg, err := bot.SendMessage(chatID, "Test message with keyboard that should be edited.",
+		InlineKeyboardMarkup{
+			InlineKeyboard: [][]InlineKeyboardButton{{{Text: "Hello", CallbackData: "hello"}}},
+		})
+	if err != nil {
+		t.Fatalf("Error sending message: %v", err)
+	}
+
+	// edit keyboard
+	err = bot.EditMessageReplyMarkup(chatID, msg.MessageID, InlineKeyboardMarkup{
+		InlineKeyboard: [][]InlineKeyboardButton{{{Text: "World", CallbackData: "world"}}},
+	})
+	if err != nil {
+		t.Fatalf("Error editing message keyboard: %v", err)
+	}
+}
+
 func TestBot_DeleteMessage(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode.")
@@ -22,6 +22,8 @@ type User struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Username  string `json:"username"`
+	// LanguageCode is the IETF language tag of the user's language.
+	LanguageCode string `json:"language_code"`
 }
 
 // File object represents a file ready to be downloaded.
@@ -58,6 +60,7 @@ type Message struct {
 	Date      int64       `json:"date"`
 	Text      string      `json:"text"`
 	Photo     []PhotoSize `json:"photo"`
+	Caption   string      `json:"caption"`
 	Document  Document    `json:"document"`
 }
 
@@ -0,0 +1,202 @@
+// Package users implements storage of the users of the bot
+// and of the restrictions that the admins set for them.
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/lazy-void/primitive-bot/internal/fileutil"
+)
+
+// Store keeps the users and their restrictions in a JSON file.
+type Store struct {
+	path string
+	data data
+	mu   sync.Mutex
+}
+
+// data is the content of the file.
+type data struct {
+	// Users are the IDs of the users that have used the bot.
+	Users map[int64]bool `json:"users"`
+	// Banned are the IDs of the users that can't use the bot.
+	Banned map[int64]bool `json:"banned"`
+	// Allowed are the IDs of the users that can use the
+	// bot when it serves only the users from this list.
+	Allowed map[int64]bool `json:"allowed"`
+	// Limits are the numbers of operations that the users can
+	// add to the queue, if they differ from the default one.
+	Limits map[int64]int `json:"limits"`
+}
+
+// NewStore initializes new instance of Store that keeps the
+// data in the file at path. The file is read if it exists.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: data{
+			Users:   make(map[int64]bool),
+			Banned:  make(map[int64]bool),
+			Allowed: make(map[int64]bool),
+			Limits:  make(map[int64]int),
+		},
+	}
+
+	content, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &s.data); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Add remembers the user, so they receive the broadcasts.
+func (s *Store) Add(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Users[userID] {
+		return nil
+	}
+	s.data.Users[userID] = true
+	return s.write()
+}
+
+// Users returns IDs of the users that have used the bot in ascending order.
+func (s *Store) Users() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return keys(s.data.Users)
+}
+
+// Ban adds the user to the list of the users that can't use the bot.
+func (s *Store) Ban(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Banned[userID] = true
+	return s.write()
+}
+
+// Unban removes the user from the list of the banned users.
+// It reports whether the user was banned.
+func (s *Store) Unban(userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.data.Banned[userID] {
+		return false, nil
+	}
+	delete(s.data.Banned, userID)
+	return true, s.write()
+}
+
+// Banned reports whether the user is banned.
+func (s *Store) Banned(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.Banned[userID]
+}
+
+// BannedUsers returns IDs of the banned users in ascending order.
+func (s *Store) BannedUsers() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return keys(s.data.Banned)
+}
+
+// Allow adds the user to the list of the users that can use the bot.
+func (s *Store) Allow(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Allowed[userID] = true
+	return s.write()
+}
+
+// Disallow removes the user from the list of the allowed
+// users. It reports whether the user was allowed.
+func (s *Store) Disallow(userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.data.Allowed[userID] {
+		return false, nil
+	}
+	delete(s.data.Allowed, userID)
+	return true, s.write()
+}
+
+// Allowed reports whether the user is in the list of the allowed users.
+func (s *Store) Allowed(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.Allowed[userID]
+}
+
+// AllowedUsers returns IDs of the allowed users in ascending order.
+func (s *Store) AllowedUsers() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return keys(s.data.Allowed)
+}
+
+// SetLimit sets the number of operations that the user can add to the
+// queue. Zero removes the limit of the user, so the default one is used.
+func (s *Store) SetLimit(userID int64, n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n == 0 {
+		delete(s.data.Limits, userID)
+	} else {
+		s.data.Limits[userID] = n
+	}
+	return s.write()
+}
+
+// Limit returns the number of operations that the user can add to the
+// queue. If the limit of the user isn't set, second parameter will be
+// equal to false.
+func (s *Store) Limit(userID int64) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.data.Limits[userID]
+	return n, ok
+}
+
+// write replaces the content of the file. It must be called with the lock held.
+func (s *Store) write() error {
+	content, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+
+	return fileutil.WriteFile(s.path, content)
+}
+
+func keys(m map[int64]bool) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,130 @@
+package users
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore_KeepsDataAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int64{3, 1, 2, 1} {
+		if err := s.Add(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Ban(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetLimit(3, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Allow(1); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{1, 2, 3}; !reflect.DeepEqual(s.Users(), expected) {
+		t.Errorf("Users() = %v; want %v", s.Users(), expected)
+	}
+	if !s.Banned(2) || s.Banned(1) {
+		t.Errorf("BannedUsers() = %v; want [2]", s.BannedUsers())
+	}
+	if expected := []int64{1}; !reflect.DeepEqual(s.AllowedUsers(), expected) {
+		t.Errorf("AllowedUsers() = %v; want %v", s.AllowedUsers(), expected)
+	}
+	if n, ok := s.Limit(3); !ok || n != 10 {
+		t.Errorf("Limit(3) = %d, %t; want 10, true", n, ok)
+	}
+}
+
+func TestStore_Unban(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Ban(1); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userID   int64
+		expected bool
+	}{
+		{1, true},
+		// the user is already unbanned
+		{1, false},
+		{2, false},
+	}
+
+	for _, tt := range tests {
+		ok, err := s.Unban(tt.userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.expected {
+			t.Errorf("Unban(%d) = %t; want %t", tt.userID, ok, tt.expected)
+		}
+	}
+	if s.Banned(1) {
+		t.Error("the user is still banned")
+	}
+}
+
+func TestStore_Disallow(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Allow(1); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userID   int64
+		expected bool
+	}{
+		{1, true},
+		// the user is already disallowed
+		{1, false},
+		{2, false},
+	}
+
+	for _, tt := range tests {
+		ok, err := s.Disallow(tt.userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.expected {
+			t.Errorf("Disallow(%d) = %t; want %t", tt.userID, ok, tt.expected)
+		}
+	}
+	if s.Allowed(1) {
+		t.Error("the user is still allowed")
+	}
+}
+
+func TestStore_SetLimitToZeroRemovesLimit(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SetLimit(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetLimit(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := s.Limit(1); ok {
+		t.Errorf("Limit(1) = %d, true; want false", n)
+	}
+}